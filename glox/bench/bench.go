@@ -0,0 +1,282 @@
+// Package bench Runs the Lox programs of the corpus through each phase
+// separately, to compare the time and allocations against a baseline
+package bench
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/interpreter"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+	"github.com/madraceee/interpreters/glox/vm"
+)
+
+//go:embed *.lox
+var corpus embed.FS
+
+// BASELINE File the results are compared against, relative to the glox directory.
+// glox bench fails if it is missing, so run it from there
+const BASELINE = "bench/baseline.json"
+
+// TIME_THRESHOLD How much slower counts as a regression. Timings are noisy,
+// so only large changes are flagged
+const TIME_THRESHOLD = 0.25
+
+// ALLOCS_THRESHOLD How many more allocations count as a regression
+const ALLOCS_THRESHOLD = 0.05
+
+// Program A Lox program of the corpus, named after its file
+type Program struct {
+	Name   string
+	Source string
+}
+
+// Programs Returns the programs of the corpus sorted by name
+func Programs() []Program {
+	entries, _ := corpus.ReadDir(".")
+	programs := make([]Program, 0, len(entries))
+	for _, entry := range entries {
+		source, _ := corpus.ReadFile(entry.Name())
+		programs = append(programs, Program{
+			Name:   strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())),
+			Source: string(source),
+		})
+	}
+
+	sort.Slice(programs, func(a, b int) bool { return programs[a].Name < programs[b].Name })
+	return programs
+}
+
+// Phase A part of running a program. Only the part itself is timed,
+// whatever it needs from the earlier phases is done before
+type Phase struct {
+	Name string
+	Run  func(b *testing.B, source string)
+}
+
+var Phases = []Phase{
+	{"scan", Scan},
+	{"parse", Parse},
+	{"interpret", Interpret},
+	{"vm", VM},
+}
+
+// Scan Times scanning the source into tokens
+func Scan(b *testing.B, source string) {
+	for k := 0; k < b.N; k++ {
+		scanner.NewScanner(source, diagnostics.NewCollector()).ScanTokens()
+	}
+}
+
+// Parse Times parsing the tokens of the source
+func Parse(b *testing.B, source string) {
+	tokens := scanner.NewScanner(source, diagnostics.NewCollector()).ScanTokens()
+
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		if _, err := parser.NewParser(tokens, diagnostics.NewCollector()).Parse(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Interpret Times the tree-walker running the resolved statements
+func Interpret(b *testing.B, source string) {
+	stmts := parse(b, source)
+
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		b.StopTimer()
+		diag := diagnostics.NewCollector()
+		i := interpreter.NewInterpreter(diag)
+		i.SetIO(strings.NewReader(""), io.Discard)
+		resolver.NewResolver(i, diag).Resolve(stmts)
+		b.StartTimer()
+
+		if err := i.Interpret(stmts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// VM Times the bytecode VM running the compiled source
+func VM(b *testing.B, source string) {
+	diag := diagnostics.NewCollector()
+	script, err := compiler.NewCompiler(diag).Compile(parse(b, source))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		b.StopTimer()
+		machine := vm.New(diag)
+		machine.SetIO(strings.NewReader(""), io.Discard)
+		b.StartTimer()
+
+		if err := machine.Run(script); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// parse Returns the statements of the source, which has to be free of static errors
+func parse(b *testing.B, source string) []parser.Stmt {
+	diag := diagnostics.NewCollector()
+	stmts, err := parser.NewParser(scanner.NewScanner(source, diag).ScanTokens(), diag).Parse()
+	if err != nil || diag.HasErrors() {
+		b.Fatalf("parse error: %v", diag.Diagnostics())
+	}
+	return stmts
+}
+
+// Result The cost of one phase of one program
+type Result struct {
+	Program     string `json:"program"`
+	Phase       string `json:"phase"`
+	NsPerOp     int64  `json:"ns_per_op"`
+	AllocsPerOp int64  `json:"allocs_per_op"`
+	BytesPerOp  int64  `json:"bytes_per_op"`
+}
+
+// RUNS Times every phase is benchmarked, the fastest run is kept
+const RUNS = 3
+
+// BENCH_TIME How long each run takes
+const BENCH_TIME = "300ms"
+
+// Run Benchmarks every phase of every program
+func Run() []Result {
+	// Outside of go test the benchtime flag only exists once it is registered
+	testing.Init()
+	flag.Set("test.benchtime", BENCH_TIME)
+
+	results := make([]Result, 0)
+	for _, program := range Programs() {
+		for _, phase := range Phases {
+			var best testing.BenchmarkResult
+			for run := 0; run < RUNS; run++ {
+				result := testing.Benchmark(func(b *testing.B) {
+					b.ReportAllocs()
+					phase.Run(b, program.Source)
+				})
+				if run == 0 || result.NsPerOp() < best.NsPerOp() {
+					best = result
+				}
+			}
+
+			results = append(results, Result{
+				Program:     program.Name,
+				Phase:       phase.Name,
+				NsPerOp:     best.NsPerOp(),
+				AllocsPerOp: best.AllocsPerOp(),
+				BytesPerOp:  best.AllocedBytesPerOp(),
+			})
+		}
+	}
+	return results
+}
+
+// Load Reads the results saved by Save
+func Load(file string) ([]Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0)
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("Can't read baseline %s: %v", file, err)
+	}
+	return results, nil
+}
+
+// Save Writes the results so later runs can be compared against them
+func Save(file string, results []Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+// Comparison A result along with the baseline result of the same program and phase
+type Comparison struct {
+	Result
+	// Nil if the baseline has no result for the program and phase
+	Baseline *Result
+	// Change relative to the baseline, 0.1 is 10% more
+	Time   float64
+	Allocs float64
+}
+
+// Regressed Returns true if the result is slower or allocates
+// more than the baseline by over the thresholds
+func (c Comparison) Regressed() bool {
+	return c.Baseline != nil && (c.Time > TIME_THRESHOLD || c.Allocs > ALLOCS_THRESHOLD)
+}
+
+// Compare Pairs every result with its baseline result
+func Compare(baseline, results []Result) []Comparison {
+	type key struct{ program, phase string }
+	previous := make(map[key]*Result, len(baseline))
+	for k := range baseline {
+		previous[key{baseline[k].Program, baseline[k].Phase}] = &baseline[k]
+	}
+
+	comparisons := make([]Comparison, 0, len(results))
+	for _, result := range results {
+		comparison := Comparison{Result: result}
+		if base, ok := previous[key{result.Program, result.Phase}]; ok {
+			comparison.Baseline = base
+			comparison.Time = change(base.NsPerOp, result.NsPerOp)
+			comparison.Allocs = change(base.AllocsPerOp, result.AllocsPerOp)
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+func change(before, after int64) float64 {
+	if before == 0 {
+		if after == 0 {
+			return 0
+		}
+		return 1
+	}
+	return float64(after-before) / float64(before)
+}
+
+// Report Writes a table of the comparisons, marking the regressions.
+// Returns the number of regressions
+func Report(w io.Writer, comparisons []Comparison) int {
+	regressions := 0
+	fmt.Fprintf(w, "%-10s %-10s %14s %8s %12s %8s\n", "program", "phase", "ns/op", "delta", "allocs/op", "delta")
+	for _, c := range comparisons {
+		time, allocs, mark := "new", "new", ""
+		if c.Baseline != nil {
+			time, allocs = percent(c.Time), percent(c.Allocs)
+		}
+		if c.Regressed() {
+			mark = "  REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(w, "%-10s %-10s %14d %8s %12d %8s%s\n", c.Program, c.Phase, c.NsPerOp, time, c.AllocsPerOp, allocs, mark)
+	}
+	return regressions
+}
+
+func percent(change float64) string {
+	return fmt.Sprintf("%+.1f%%", change*100)
+}
@@ -0,0 +1,92 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox"
+)
+
+// benchmark Runs the phase for every program of the corpus
+func benchmark(b *testing.B, phase func(b *testing.B, source string)) {
+	for _, program := range Programs() {
+		b.Run(program.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			phase(b, program.Source)
+		})
+	}
+}
+
+func BenchmarkScan(b *testing.B)      { benchmark(b, Scan) }
+func BenchmarkParse(b *testing.B)     { benchmark(b, Parse) }
+func BenchmarkInterpret(b *testing.B) { benchmark(b, Interpret) }
+func BenchmarkVM(b *testing.B)        { benchmark(b, VM) }
+
+func TestProgramsRunOnBothEngines(t *testing.T) {
+	for _, program := range Programs() {
+		t.Run(program.Name, func(t *testing.T) {
+			outputs := make([]string, 0, 2)
+			for _, engine := range []glox.Engine{glox.TREE_WALKER, glox.BYTECODE} {
+				out := &bytes.Buffer{}
+				vm := glox.New(glox.Options{Stdout: out, Engine: engine})
+				if err := vm.Eval(context.Background(), program.Source); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				outputs = append(outputs, out.String())
+			}
+
+			if outputs[0] == "" || outputs[0] != outputs[1] {
+				t.Errorf("vm printed %q, tree-walker printed %q", outputs[1], outputs[0])
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := []Result{
+		{Program: "fib", Phase: "scan", NsPerOp: 1000, AllocsPerOp: 10},
+		{Program: "fib", Phase: "parse", NsPerOp: 1000, AllocsPerOp: 10},
+		{Program: "fib", Phase: "vm", NsPerOp: 1000, AllocsPerOp: 10},
+	}
+	results := []Result{
+		{Program: "fib", Phase: "scan", NsPerOp: 1200, AllocsPerOp: 10},
+		{Program: "fib", Phase: "parse", NsPerOp: 900, AllocsPerOp: 11},
+		{Program: "fib", Phase: "vm", NsPerOp: 1300, AllocsPerOp: 8},
+		{Program: "loops", Phase: "scan", NsPerOp: 5000, AllocsPerOp: 50},
+	}
+
+	comparisons := Compare(baseline, results)
+	want := []bool{false, true, true, false}
+	for k, c := range comparisons {
+		if got := c.Regressed(); got != want[k] {
+			t.Errorf("%s %s regressed = %v, want %v", c.Program, c.Phase, got, want[k])
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if got := Report(out, comparisons); got != 2 {
+		t.Errorf("regressions = %d, want 2", got)
+	}
+	if got := strings.Count(out.String(), "REGRESSION"); got != 2 {
+		t.Errorf("report marks %d regressions, want 2:\n%s", got, out.String())
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "baseline.json")
+	results := []Result{{Program: "fib", Phase: "scan", NsPerOp: 1000, AllocsPerOp: 10, BytesPerOp: 512}}
+	if err := Save(file, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Load(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0] != results[0] {
+		t.Errorf("loaded %v, want %v", loaded, results)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+func astPrinter() {
+	_ = parser.NewBinary(
+		parser.NewUnary(
+			token.NewToken(token.MINUS, "-", nil, 1, 1),
+			parser.NewLiteral(value.Number(123)),
+		),
+		token.NewToken(token.STAR, "*", nil, 1, 6),
+		parser.NewGrouping(
+			parser.NewLiteral(value.Number(45.67)),
+		),
+	)
+
+	// ap := parser.NewAstPrinter()
+	// ap.Print(expr)
+}
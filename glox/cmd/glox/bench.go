@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/madraceee/interpreters/glox/bench"
+)
+
+// runBench Benchmarks the corpus and compares the results against the baseline,
+// exiting with an error on a regression. With save the results become the baseline
+func runBench(args []string) {
+	save := len(args) > 0 && args[0] == "save"
+	if save {
+		args = args[1:]
+	}
+	baseline := bench.BASELINE
+	if len(args) > 0 {
+		baseline = args[0]
+	}
+
+	if save {
+		results := bench.Run()
+		if err := bench.Save(baseline, results); err != nil {
+			fmt.Printf("Cannot write baseline %s: %v\n", baseline, err)
+			os.Exit(1)
+		}
+		bench.Report(os.Stdout, bench.Compare(nil, results))
+		fmt.Printf("Saved baseline %s\n", baseline)
+		return
+	}
+
+	// Without a baseline every result would pass as new
+	previous, err := bench.Load(baseline)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("Cannot find baseline %s. Run glox bench from the glox directory, pass the baseline or create it with glox bench save\n", baseline)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if regressions := bench.Report(os.Stdout, bench.Compare(previous, bench.Run())); regressions > 0 {
+		fmt.Printf("%d regressions against %s\n", regressions, baseline)
+		os.Exit(1)
+	}
+}
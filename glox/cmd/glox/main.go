@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/madraceee/interpreters/glox"
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/utils"
+)
+
+// engine Runs the scripts, set to the bytecode VM by -vm
+var engine = glox.TREE_WALKER
+
+func main() {
+	args := os.Args
+	utils.Debug = false
+	if len(args) > 1 && args[1] == "-vm" {
+		engine = glox.BYTECODE
+		args = append(args[:1], args[2:]...)
+	}
+	if len(args) > 1 && args[1] == "bench" && len(args) <= 4 {
+		runBench(args[2:])
+	} else if len(args) > 3 {
+		fmt.Printf("%s\n", "Usage: glox [-vm] [debug] [script] | glox disasm|compile script | glox bench [save] [baseline]")
+		os.Exit(1)
+	} else if len(args) == 3 && (args[1] == "disasm" || args[1] == "compile") {
+		compileFile(args[1], args[2])
+	} else if len(args) == 3 {
+		if args[1] == "debug" {
+			utils.Debug = true
+		}
+		runFile(args[2])
+	} else if len(args) == 2 {
+		runFile(args[1])
+	} else {
+		runPrompt()
+	}
+}
+
+// newVM Returns a VM on the engine chosen by the flags
+func newVM(fileName string) *glox.VM {
+	return glox.New(glox.Options{
+		FileName:   fileName,
+		SearchPath: searchPath(),
+		Engine:     engine,
+	})
+}
+
+// run Runs the source on the VM and prints the errors found.
+// Returns false if there were any errors
+func run(vm *glox.VM, source string) bool {
+	// Ctrl-C stops the script instead of killing the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := vm.Eval(ctx, source); err != nil {
+		fmt.Println(err)
+		return false
+	}
+	return true
+}
+
+// searchPath Returns the directories in GLOX_PATH which are searched for imported modules
+func searchPath() []string {
+	if path := os.Getenv("GLOX_PATH"); path != "" {
+		return filepath.SplitList(path)
+	}
+	return nil
+}
+
+// runFile Runs the script, or the compiled script if it ends in .loxc
+func runFile(fileName string) {
+	content := readFile(fileName)
+	if filepath.Ext(fileName) == COMPILED_EXT {
+		if !runCompiled(fileName, content) {
+			os.Exit(1)
+		}
+		return
+	}
+	if !run(newVM(fileName), string(content)) {
+		os.Exit(1)
+	}
+}
+
+// COMPILED_EXT Extension of the files written by glox compile
+const COMPILED_EXT = ".loxc"
+
+// runCompiled Runs the compiled script on the bytecode VM
+func runCompiled(fileName string, content []byte) bool {
+	script, err := compiler.Decode(bytes.NewReader(content))
+	if err != nil {
+		fmt.Printf("Cannot load %s: %v\n", fileName, err)
+		return false
+	}
+
+	vm := glox.New(glox.Options{
+		FileName:   fileName,
+		SearchPath: searchPath(),
+		Engine:     glox.BYTECODE,
+	})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := vm.Run(ctx, script); err != nil {
+		fmt.Println(err)
+		return false
+	}
+	return true
+}
+
+// compileFile Prints the bytecode of the script for disasm,
+// or writes it next to the script with the .loxc extension for compile
+func compileFile(command, fileName string) {
+	vm := glox.New(glox.Options{FileName: fileName, Engine: glox.BYTECODE})
+	script, err := vm.Compile(string(readFile(fileName)))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if command == "disasm" {
+		compiler.Disassemble(os.Stdout, script)
+		return
+	}
+
+	out := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + COMPILED_EXT
+	file, err := os.Create(out)
+	if err != nil {
+		fmt.Printf("Cannot create file %s\n", out)
+		os.Exit(1)
+	}
+	defer file.Close()
+	if err := compiler.Encode(file, script); err != nil {
+		fmt.Printf("Cannot write file %s: %v\n", out, err)
+		os.Exit(1)
+	}
+}
+
+func readFile(fileName string) []byte {
+	file, err := os.Open(fileName)
+	if err != nil {
+		utils.DLogf("Cannot open file %s: %v", fileName, err.Error())
+		fmt.Printf("Cannot open file %s\n", fileName)
+		os.Exit(1)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		utils.DLogf("Error reading file%s: %v", fileName, err.Error())
+		fmt.Printf("Error reading file %s\n", fileName)
+		os.Exit(1)
+	}
+	return content
+}
+
+// runPrompt Runs each line on the same VM, so the globals
+// declared on one line can be used on the next
+func runPrompt() {
+	vm := newVM("repl")
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("> ")
+		text, _ := reader.ReadString('\n')
+		if len(text) == 0 {
+			break
+		}
+		run(vm, text)
+		fmt.Println("")
+	}
+}
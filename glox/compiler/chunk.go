@@ -0,0 +1,147 @@
+package compiler
+
+import (
+	"sort"
+
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+type OpCode byte
+
+// Operands follow the opcode in the code. Constants, names and
+// jump offsets take two bytes, big endian, everything else one
+const (
+	// OP_CONSTANT index: push the constant
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	// OP_GET_LOCAL slot, OP_SET_LOCAL slot: slots count from the frame's callee
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	// OP_GET_GLOBAL name, OP_DEFINE_GLOBAL name, OP_SET_GLOBAL name
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	OP_SET_GLOBAL
+	// OP_GET_UPVALUE index, OP_SET_UPVALUE index
+	OP_GET_UPVALUE
+	OP_SET_UPVALUE
+	// OP_GET_PROPERTY name, OP_SET_PROPERTY name, OP_GET_SUPER name
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+	OP_GET_SUPER
+	OP_GET_INDEX
+	OP_SET_INDEX
+	OP_EQUAL
+	OP_GREATER
+	OP_GREATER_EQUAL
+	OP_LESS
+	OP_LESS_EQUAL
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_NOT
+	OP_NEGATE
+	OP_PRINT
+	// OP_JUMP offset, OP_JUMP_IF_FALSE offset: jump forward, the condition is kept
+	OP_JUMP
+	OP_JUMP_IF_FALSE
+	// OP_LOOP offset: jump backward
+	OP_LOOP
+	// OP_CALL argCount
+	OP_CALL
+	// OP_CLOSURE function, then isLocal and index for each upvalue
+	OP_CLOSURE
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+	// OP_CLASS name
+	OP_CLASS
+	OP_INHERIT
+	// OP_METHOD name
+	OP_METHOD
+	// OP_LIST count, OP_MAP count: build from the elements or key value pairs on the stack
+	OP_LIST
+	OP_MAP
+	// OP_ITERATE replaces the iterable with an iterator
+	OP_ITERATE
+	// OP_FOR_NEXT offset: push the next value of the iterator or jump forward once done
+	OP_FOR_NEXT
+	// OP_IMPORT path
+	OP_IMPORT
+	OP_THROW
+	// OP_TRY offset: errors raised till OP_END_TRY jump forward with the error pushed
+	OP_TRY
+	OP_END_TRY
+	// OP_CATCH replaces the error with the value the catch clause binds
+	OP_CATCH
+	// OP_RETHROW raises the error pushed by OP_TRY again
+	OP_RETHROW
+)
+
+// LineStart Instructions from Offset till the next LineStart
+// were compiled from the code in Span
+type LineStart struct {
+	Offset int
+	Span   token.Span
+}
+
+// Chunk Bytecode of a function along with its constants and line table
+type Chunk struct {
+	Code      []byte
+	Constants []value.Value
+	// Run-length encoded, a new entry starts whenever the span changes
+	Lines []LineStart
+	// Index of the constants which are shared
+	shared map[value.Value]int
+}
+
+func NewChunk() *Chunk {
+	return &Chunk{
+		Code:      make([]byte, 0),
+		Constants: make([]value.Value, 0),
+		Lines:     make([]LineStart, 0),
+		shared:    make(map[value.Value]int),
+	}
+}
+
+// Write Appends the bytes compiled from the code in the span
+func (c *Chunk) Write(span token.Span, bytes ...byte) {
+	if len(c.Lines) == 0 || c.Lines[len(c.Lines)-1].Span != span {
+		c.Lines = append(c.Lines, LineStart{Offset: len(c.Code), Span: span})
+	}
+	c.Code = append(c.Code, bytes...)
+}
+
+// AddConstant Returns the index of the constant, adding it to the pool if it is new.
+// Only nil, booleans, numbers and strings are shared
+func (c *Chunk) AddConstant(v value.Value) int {
+	switch v.(type) {
+	case value.NilValue, value.Bool, value.Number, value.String:
+		if k, ok := c.shared[v]; ok {
+			return k
+		}
+		c.shared[v] = len(c.Constants)
+	}
+
+	c.Constants = append(c.Constants, v)
+	return len(c.Constants) - 1
+}
+
+// SpanAt Returns the span of the code the instruction at the offset was compiled from
+func (c *Chunk) SpanAt(offset int) token.Span {
+	k := sort.Search(len(c.Lines), func(k int) bool {
+		return c.Lines[k].Offset > offset
+	})
+	if k == 0 {
+		return token.Span{}
+	}
+	return c.Lines[k-1].Span
+}
+
+// LineAt Returns the source line of the instruction at the offset
+func (c *Chunk) LineAt(offset int) int {
+	return c.SpanAt(offset).Start.Line
+}
@@ -0,0 +1,900 @@
+package compiler
+
+import (
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// MAX_LOCALS Locals, including parameters, a function can have at once
+const MAX_LOCALS = 256
+
+// MAX_UPVALUES Variables a function can capture from the functions enclosing it
+const MAX_UPVALUES = 256
+
+// MAX_CONSTANTS Constants a chunk can hold, indexed by two bytes
+const MAX_CONSTANTS = 1 << 16
+
+type FunctionType int
+
+const (
+	SCRIPT FunctionType = iota
+	FUNCTION
+	METHOD
+	INITIALIZER
+)
+
+// local A variable on the stack of the function being compiled
+type local struct {
+	name string
+	// Scope depth, or -1 till the variable is initialized
+	depth int
+	// Captured locals are moved off the stack when they go out of scope
+	isCaptured bool
+}
+
+// upvalue A variable captured from the enclosing function.
+// index is a local slot of the enclosing function if isLocal,
+// else one of its upvalues
+type upvalue struct {
+	index   byte
+	isLocal bool
+}
+
+// loop A loop whose body is being compiled
+type loop struct {
+	// Where continue jumps back to, or -1 to jump forward to the increment
+	start int
+	// Offsets of the jumps to patch once the end of the loop is known
+	breaks    []int
+	continues []int
+	// Scope depth outside the body and the number of trys around the loop
+	scopeDepth int
+	tries      int
+}
+
+// try A try statement whose handler is active while its body,
+// or its catch body if it has a finally body, is being compiled
+type try struct {
+	finallyBody []parser.Stmt
+	// Number of loops around the try statement
+	loops int
+}
+
+// function State of a function which is being compiled
+type function struct {
+	enclosing    *function
+	function     *Function
+	functionType FunctionType
+	locals       []local
+	upvalues     []upvalue
+	scopeDepth   int
+	loops        []*loop
+	tries        []*try
+}
+
+// Compiler Lowers resolved statements to bytecode.
+// Variables are resolved again into stack slots and upvalues
+type Compiler struct {
+	current *function
+	errors  diagnostics.List
+	diag    *diagnostics.Collector
+}
+
+func NewCompiler(diag *diagnostics.Collector) *Compiler {
+	return &Compiler{
+		errors: make(diagnostics.List, 0),
+		diag:   diag,
+	}
+}
+
+// Compile Compiles the statements of a script or module into a function without parameters.
+// Statements must have been resolved without errors
+func (c *Compiler) Compile(stmts []parser.Stmt) (*Function, error) {
+	c.beginFunction(NewFunction("", 0), SCRIPT)
+	for _, stmt := range stmts {
+		c.statement(stmt)
+	}
+
+	span := token.Span{}
+	if len(stmts) > 0 {
+		span = stmts[len(stmts)-1].Span()
+	}
+	script := c.endFunction(span)
+
+	if len(c.errors) > 0 {
+		return script, c.errors
+	}
+	return script, nil
+}
+
+func (c *Compiler) error(span token.Span, message string) {
+	d := c.diag.Error(diagnostics.COMPILE_ERROR, span, message)
+	c.errors = append(c.errors, d)
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return c.current.function.Chunk
+}
+
+func (c *Compiler) statement(stmt parser.Stmt) {
+	stmt.Visit(c)
+}
+
+func (c *Compiler) expression(expr parser.Expr) {
+	expr.Visit(c)
+}
+
+// Emitting
+func (c *Compiler) emit(span token.Span, op OpCode, operands ...byte) {
+	c.chunk().Write(span, append([]byte{byte(op)}, operands...)...)
+}
+
+// short Returns the two byte operand of n
+func short(n int) []byte {
+	return []byte{byte(n >> 8), byte(n)}
+}
+
+func (c *Compiler) makeConstant(span token.Span, v value.Value) int {
+	constant := c.chunk().AddConstant(v)
+	if constant >= MAX_CONSTANTS {
+		c.error(span, "Too many constants in one chunk.")
+		return 0
+	}
+	return constant
+}
+
+func (c *Compiler) identifierConstant(name token.Token) int {
+	return c.makeConstant(name.Span(), value.String(name.Lexeme))
+}
+
+// emitJump Emits the jump with a placeholder offset and returns where the offset is
+func (c *Compiler) emitJump(span token.Span, op OpCode) int {
+	c.emit(span, op, 0xff, 0xff)
+	return len(c.chunk().Code) - 2
+}
+
+// patchJump Makes the jump at the offset land on the next instruction
+func (c *Compiler) patchJump(span token.Span, offset int) {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump >= 1<<16 {
+		c.error(span, "Too much code to jump over.")
+	}
+
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+}
+
+// emitLoop Emits a jump back to the start
+func (c *Compiler) emitLoop(span token.Span, start int) {
+	offset := len(c.chunk().Code) + 3 - start
+	if offset >= 1<<16 {
+		c.error(span, "Loop body too large.")
+	}
+	c.emit(span, OP_LOOP, short(offset)...)
+}
+
+// emitReturn Emits the return at the end of a function body.
+// Initializers return the instance and everything else nil
+func (c *Compiler) emitReturn(span token.Span) {
+	if c.current.functionType == INITIALIZER {
+		c.emit(span, OP_GET_LOCAL, 0)
+	} else {
+		c.emit(span, OP_NIL)
+	}
+	c.emit(span, OP_RETURN)
+}
+
+// Functions
+func (c *Compiler) beginFunction(fn *Function, functionType FunctionType) {
+	c.current = &function{
+		enclosing:    c.current,
+		function:     fn,
+		functionType: functionType,
+		locals:       make([]local, 0, 8),
+		upvalues:     make([]upvalue, 0),
+		loops:        make([]*loop, 0),
+		tries:        make([]*try, 0),
+	}
+
+	// Slot zero holds the callee, which methods see as "this"
+	name := ""
+	if functionType == METHOD || functionType == INITIALIZER {
+		name = "this"
+	}
+	c.current.locals = append(c.current.locals, local{name: name, depth: 0})
+}
+
+// endFunction Finishes the function being compiled and returns to the enclosing one
+func (c *Compiler) endFunction(span token.Span) *Function {
+	c.emitReturn(span)
+
+	fn := c.current.function
+	fn.UpvalueCount = len(c.current.upvalues)
+	c.current = c.current.enclosing
+	return fn
+}
+
+// function Compiles the declaration into a closure pushed on the stack
+func (c *Compiler) function(declaration *parser.Function, functionType FunctionType) {
+	// Lambdas are named by their 'fun' or '(' token
+	name := declaration.Name.Lexeme
+	if declaration.Name.TokenType != token.IDENTIFIER {
+		name = "anonymous"
+	}
+
+	c.beginFunction(NewFunction(name, len(declaration.Params)), functionType)
+	c.beginScope()
+	for _, param := range declaration.Params {
+		c.addLocal(param)
+		c.markInitialized()
+	}
+	for _, stmt := range declaration.Body {
+		c.statement(stmt)
+	}
+
+	upvalues := c.current.upvalues
+	fn := c.endFunction(declaration.Span())
+
+	span := declaration.Name.Span()
+	operands := short(c.makeConstant(span, fn))
+	for _, up := range upvalues {
+		isLocal := byte(0)
+		if up.isLocal {
+			isLocal = 1
+		}
+		operands = append(operands, isLocal, up.index)
+	}
+	c.emit(span, OP_CLOSURE, operands...)
+}
+
+// Scopes and variables
+func (c *Compiler) beginScope() {
+	c.current.scopeDepth++
+}
+
+// endScope Removes the locals of the innermost scope from the stack
+func (c *Compiler) endScope(span token.Span) {
+	c.current.scopeDepth--
+	c.discardLocals(c.current.scopeDepth, span)
+
+	locals := c.current.locals
+	for len(locals) > 0 && locals[len(locals)-1].depth > c.current.scopeDepth {
+		locals = locals[:len(locals)-1]
+	}
+	c.current.locals = locals
+}
+
+// discardLocals Emits the instructions removing the locals deeper than the depth
+// from the stack, without forgetting them. Used to jump out of scopes
+func (c *Compiler) discardLocals(depth int, span token.Span) {
+	locals := c.current.locals
+	for k := len(locals) - 1; k >= 0 && locals[k].depth > depth; k-- {
+		if locals[k].isCaptured {
+			c.emit(span, OP_CLOSE_UPVALUE)
+		} else {
+			c.emit(span, OP_POP)
+		}
+	}
+}
+
+func (c *Compiler) addLocal(name token.Token) {
+	c.addHiddenLocal(name.Span(), name.Lexeme)
+}
+
+// addHiddenLocal Adds a local which holds a value of the compiler.
+// Hidden locals are unnamed so they can't be referenced from the source
+func (c *Compiler) addHiddenLocal(span token.Span, name string) {
+	if len(c.current.locals) == MAX_LOCALS {
+		c.error(span, "Too many local variables in function.")
+		return
+	}
+	c.current.locals = append(c.current.locals, local{name: name, depth: -1})
+}
+
+func (c *Compiler) markInitialized() {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.current.locals[len(c.current.locals)-1].depth = c.current.scopeDepth
+}
+
+// declareVariable Adds a local for the variable, unless it is a global
+func (c *Compiler) declareVariable(name token.Token) {
+	if c.current.scopeDepth == 0 {
+		return
+	}
+	c.addLocal(name)
+}
+
+// defineVariable Makes the value on top of the stack the variable
+func (c *Compiler) defineVariable(name token.Token) {
+	if c.current.scopeDepth > 0 {
+		c.markInitialized()
+		return
+	}
+	c.emit(name.Span(), OP_DEFINE_GLOBAL, short(c.identifierConstant(name))...)
+}
+
+func resolveLocal(f *function, name string) int {
+	for k := len(f.locals) - 1; k >= 0; k-- {
+		if f.locals[k].name == name {
+			return k
+		}
+	}
+	return -1
+}
+
+// resolveUpvalue Returns the upvalue of the function capturing the variable
+// from an enclosing function, or -1 if the variable is a global
+func (c *Compiler) resolveUpvalue(f *function, name token.Token) int {
+	if f.enclosing == nil {
+		return -1
+	}
+
+	if local := resolveLocal(f.enclosing, name.Lexeme); local != -1 {
+		f.enclosing.locals[local].isCaptured = true
+		return c.addUpvalue(f, name, byte(local), true)
+	}
+	if up := c.resolveUpvalue(f.enclosing, name); up != -1 {
+		return c.addUpvalue(f, name, byte(up), false)
+	}
+	return -1
+}
+
+func (c *Compiler) addUpvalue(f *function, name token.Token, index byte, isLocal bool) int {
+	for k, up := range f.upvalues {
+		if up.index == index && up.isLocal == isLocal {
+			return k
+		}
+	}
+
+	if len(f.upvalues) == MAX_UPVALUES {
+		c.error(name.Span(), "Too many closure variables in function.")
+		return 0
+	}
+	f.upvalues = append(f.upvalues, upvalue{index: index, isLocal: isLocal})
+	return len(f.upvalues) - 1
+}
+
+// getVariable Pushes the variable, looking for a local, then an upvalue, then a global
+func (c *Compiler) getVariable(name token.Token) {
+	if local := resolveLocal(c.current, name.Lexeme); local != -1 {
+		c.emit(name.Span(), OP_GET_LOCAL, byte(local))
+	} else if up := c.resolveUpvalue(c.current, name); up != -1 {
+		c.emit(name.Span(), OP_GET_UPVALUE, byte(up))
+	} else {
+		c.emit(name.Span(), OP_GET_GLOBAL, short(c.identifierConstant(name))...)
+	}
+}
+
+// setVariable Assigns the value on top of the stack to the variable, leaving it there
+func (c *Compiler) setVariable(name token.Token) {
+	if local := resolveLocal(c.current, name.Lexeme); local != -1 {
+		c.emit(name.Span(), OP_SET_LOCAL, byte(local))
+	} else if up := c.resolveUpvalue(c.current, name); up != -1 {
+		c.emit(name.Span(), OP_SET_UPVALUE, byte(up))
+	} else {
+		c.emit(name.Span(), OP_SET_GLOBAL, short(c.identifierConstant(name))...)
+	}
+}
+
+// Leaving loops and trys
+// exitTries Leaves the trys from the one at the index outward,
+// removing their handlers and running their finally bodies, innermost first
+func (c *Compiler) exitTries(from int, span token.Span) {
+	for k := len(c.current.tries) - 1; k >= from; k-- {
+		c.emit(span, OP_END_TRY)
+		if c.current.tries[k].finallyBody != nil {
+			c.inlineFinally(k, span)
+		}
+	}
+}
+
+// inlineFinally Compiles the finally body of the try at the index where the
+// code leaves the try, as if only the loops and trys around the try were active
+func (c *Compiler) inlineFinally(k int, span token.Span) {
+	t := c.current.tries[k]
+	loops, tries := c.current.loops, c.current.tries
+	c.current.loops, c.current.tries = loops[:t.loops], tries[:k]
+	defer func() { c.current.loops, c.current.tries = loops, tries }()
+
+	c.block(t.finallyBody, span)
+}
+
+// block Compiles the statements in a new scope
+func (c *Compiler) block(stmts []parser.Stmt, span token.Span) {
+	c.beginScope()
+	for _, stmt := range stmts {
+		c.statement(stmt)
+	}
+	c.endScope(span)
+}
+
+// Statements
+func (c *Compiler) VisitBlockStmt(b *parser.Block) (interface{}, error) {
+	c.block(b.Statements, b.Span())
+	return nil, nil
+}
+
+func (c *Compiler) VisitBreakStmt(b *parser.Break) (interface{}, error) {
+	l := c.current.loops[len(c.current.loops)-1]
+	span := b.Keyword.Span()
+
+	c.exitTries(l.tries, span)
+	c.discardLocals(l.scopeDepth, span)
+	l.breaks = append(l.breaks, c.emitJump(span, OP_JUMP))
+	return nil, nil
+}
+
+func (c *Compiler) VisitContinueStmt(ct *parser.Continue) (interface{}, error) {
+	l := c.current.loops[len(c.current.loops)-1]
+	span := ct.Keyword.Span()
+
+	c.exitTries(l.tries, span)
+	c.discardLocals(l.scopeDepth, span)
+	if l.start == -1 {
+		l.continues = append(l.continues, c.emitJump(span, OP_JUMP))
+	} else {
+		c.emitLoop(span, l.start)
+	}
+	return nil, nil
+}
+
+// VisitClassStmt Creates the class, then copies the methods of the superclass
+// into it and adds its own. Methods of a subclass capture "super"
+func (c *Compiler) VisitClassStmt(cl *parser.Class) (interface{}, error) {
+	span := cl.Name.Span()
+	name := c.identifierConstant(cl.Name)
+	c.declareVariable(cl.Name)
+	c.emit(span, OP_CLASS, short(name)...)
+	c.defineVariable(cl.Name)
+
+	if cl.Superclass != nil {
+		c.expression(cl.Superclass)
+
+		c.beginScope()
+		c.addHiddenLocal(cl.Superclass.Name.Span(), "super")
+		c.markInitialized()
+
+		c.getVariable(cl.Name)
+		c.emit(cl.Superclass.Name.Span(), OP_INHERIT)
+	}
+
+	c.getVariable(cl.Name)
+	for _, method := range cl.Methods {
+		functionType := METHOD
+		if method.Name.Lexeme == "init" {
+			functionType = INITIALIZER
+		}
+		c.function(method, functionType)
+		c.emit(method.Name.Span(), OP_METHOD, short(c.identifierConstant(method.Name))...)
+	}
+	c.emit(span, OP_POP)
+
+	if cl.Superclass != nil {
+		c.endScope(span)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitExpressionStmt(e *parser.Expression) (interface{}, error) {
+	c.expression(e.Expression)
+	c.emit(e.Span(), OP_POP)
+	return nil, nil
+}
+
+// VisitForInStmt Keeps the iterator in a hidden local. Every iteration
+// gets a new scope for the loop variable so closures capture the current value
+func (c *Compiler) VisitForInStmt(f *parser.ForIn) (interface{}, error) {
+	span := f.In.Span()
+	c.beginScope()
+	c.expression(f.Iterable)
+	c.emit(span, OP_ITERATE)
+	c.addHiddenLocal(span, "")
+	c.markInitialized()
+
+	start := len(c.chunk().Code)
+	exit := c.emitJump(span, OP_FOR_NEXT)
+
+	l := &loop{
+		start:      start,
+		scopeDepth: c.current.scopeDepth,
+		tries:      len(c.current.tries),
+	}
+	c.current.loops = append(c.current.loops, l)
+
+	c.beginScope()
+	c.addLocal(f.Name)
+	c.markInitialized()
+	c.statement(f.Body)
+	c.endScope(f.Span())
+
+	c.current.loops = c.current.loops[:len(c.current.loops)-1]
+	c.emitLoop(span, start)
+	c.patchJump(span, exit)
+	for _, jump := range l.breaks {
+		c.patchJump(span, jump)
+	}
+
+	c.endScope(f.Span())
+	return nil, nil
+}
+
+func (c *Compiler) VisitFunctionStmt(f *parser.Function) (interface{}, error) {
+	// Function is initialized before its body to allow recursion
+	c.declareVariable(f.Name)
+	c.markInitialized()
+	c.function(f, FUNCTION)
+	c.defineVariable(f.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIfStmt(i *parser.If) (interface{}, error) {
+	span := i.Condition.Span()
+	c.expression(i.Condition)
+
+	thenJump := c.emitJump(span, OP_JUMP_IF_FALSE)
+	c.emit(span, OP_POP)
+	c.statement(i.ThenBranch)
+
+	elseJump := c.emitJump(span, OP_JUMP)
+	c.patchJump(span, thenJump)
+	c.emit(span, OP_POP)
+	if i.ElseBranch != nil {
+		c.statement(i.ElseBranch)
+	}
+	c.patchJump(span, elseJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitImportStmt(i *parser.Import) (interface{}, error) {
+	path, _ := value.AsString(i.Path.Literal)
+	c.emit(i.Path.Span(), OP_IMPORT, short(c.makeConstant(i.Path.Span(), value.String(path)))...)
+
+	c.declareVariable(i.Name)
+	c.defineVariable(i.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitPrintStmt(p *parser.Print) (interface{}, error) {
+	c.expression(p.Expression)
+	c.emit(p.Span(), OP_PRINT)
+	return nil, nil
+}
+
+// VisitReturnStmt Runs the finally bodies of the trys being left before returning.
+// The value is kept in a hidden local while they run
+func (c *Compiler) VisitReturnStmt(r *parser.Return) (interface{}, error) {
+	span := r.Keyword.Span()
+	if r.Value != nil {
+		c.expression(r.Value)
+	} else if c.current.functionType == INITIALIZER {
+		c.emit(span, OP_GET_LOCAL, 0)
+	} else {
+		c.emit(span, OP_NIL)
+	}
+
+	if len(c.current.tries) > 0 {
+		c.addHiddenLocal(span, "")
+		c.markInitialized()
+		c.exitTries(0, span)
+		c.current.locals = c.current.locals[:len(c.current.locals)-1]
+	}
+	c.emit(span, OP_RETURN)
+	return nil, nil
+}
+
+func (c *Compiler) VisitThrowStmt(t *parser.Throw) (interface{}, error) {
+	c.expression(t.Value)
+	c.emit(t.Keyword.Span(), OP_THROW)
+	return nil, nil
+}
+
+// VisitTryStmt The handler of the try body jumps to the catch body, which is
+// protected by a second handler when there is a finally body. The finally body
+// is compiled after each of them and once more where an error is rethrown
+func (c *Compiler) VisitTryStmt(t *parser.Try) (interface{}, error) {
+	span := t.Keyword.Span()
+	ends := make([]int, 0, 2)
+
+	handler := c.protect(span, t.FinallyBody, func() {
+		c.block(t.Body, t.Span())
+	})
+	ends = append(ends, c.emitJump(span, OP_JUMP))
+	c.patchJump(span, handler)
+
+	switch {
+	case t.CatchBody == nil:
+		c.rethrow(span, t.FinallyBody, 1)
+
+	case t.FinallyBody == nil:
+		c.catch(t, func() {
+			for _, stmt := range t.CatchBody {
+				c.statement(stmt)
+			}
+		})
+
+	default:
+		c.catch(t, func() {
+			handler = c.protect(span, t.FinallyBody, func() {
+				for _, stmt := range t.CatchBody {
+					c.statement(stmt)
+				}
+			})
+		})
+		ends = append(ends, c.emitJump(span, OP_JUMP))
+		c.patchJump(span, handler)
+		// The error variable is still on the stack below the error
+		c.rethrow(span, t.FinallyBody, 2)
+	}
+
+	for _, end := range ends {
+		c.patchJump(span, end)
+	}
+	return nil, nil
+}
+
+// protect Compiles the body with a handler active, followed by the finally body.
+// Returns the jump of the handler to patch where errors are handled
+func (c *Compiler) protect(span token.Span, finallyBody []parser.Stmt, body func()) int {
+	handler := c.emitJump(span, OP_TRY)
+	c.current.tries = append(c.current.tries, &try{
+		finallyBody: finallyBody,
+		loops:       len(c.current.loops),
+	})
+	body()
+	c.current.tries = c.current.tries[:len(c.current.tries)-1]
+
+	c.emit(span, OP_END_TRY)
+	if finallyBody != nil {
+		c.block(finallyBody, span)
+	}
+	return handler
+}
+
+// catch Binds the error on the stack to the error variable for the catch body
+func (c *Compiler) catch(t *parser.Try, body func()) {
+	c.beginScope()
+	c.emit(t.CatchName.Span(), OP_CATCH)
+	c.addLocal(t.CatchName)
+	c.markInitialized()
+	body()
+	c.endScope(t.Span())
+}
+
+// rethrow Compiles the finally body run before an error is raised again.
+// The error and the values below it are hidden locals of the finally body
+func (c *Compiler) rethrow(span token.Span, finallyBody []parser.Stmt, hidden int) {
+	c.beginScope()
+	for k := 0; k < hidden; k++ {
+		c.addHiddenLocal(span, "")
+		c.markInitialized()
+	}
+	c.block(finallyBody, span)
+	c.emit(span, OP_RETHROW)
+
+	// Nothing runs after the rethrow so the locals are dropped without popping them
+	c.current.scopeDepth--
+	c.current.locals = c.current.locals[:len(c.current.locals)-hidden]
+}
+
+func (c *Compiler) VisitVarStmt(v *parser.Var) (interface{}, error) {
+	c.declareVariable(v.Name)
+	if v.Initializer != nil {
+		c.expression(v.Initializer)
+	} else {
+		c.emit(v.Name.Span(), OP_NIL)
+	}
+	c.defineVariable(v.Name)
+	return nil, nil
+}
+
+// VisitWhileStmt Continue jumps back to the condition, or forward
+// to the increment of a desugared for loop
+func (c *Compiler) VisitWhileStmt(w *parser.While) (interface{}, error) {
+	span := w.Condition.Span()
+	start := len(c.chunk().Code)
+	c.expression(w.Condition)
+	exit := c.emitJump(span, OP_JUMP_IF_FALSE)
+	c.emit(span, OP_POP)
+
+	l := &loop{
+		start:      start,
+		scopeDepth: c.current.scopeDepth,
+		tries:      len(c.current.tries),
+	}
+	if w.Increment != nil {
+		l.start = -1
+	}
+	c.current.loops = append(c.current.loops, l)
+	c.statement(w.Body)
+	c.current.loops = c.current.loops[:len(c.current.loops)-1]
+
+	if w.Increment != nil {
+		for _, jump := range l.continues {
+			c.patchJump(span, jump)
+		}
+		c.expression(w.Increment)
+		c.emit(w.Increment.Span(), OP_POP)
+	}
+	c.emitLoop(span, start)
+
+	c.patchJump(span, exit)
+	c.emit(span, OP_POP)
+	for _, jump := range l.breaks {
+		c.patchJump(span, jump)
+	}
+	return nil, nil
+}
+
+// Expressions
+func (c *Compiler) VisitAssignExpr(a *parser.Assign) (interface{}, error) {
+	c.expression(a.Value)
+	c.setVariable(a.Name)
+	return nil, nil
+}
+
+func (c *Compiler) VisitBinaryExpr(b *parser.Binary) (interface{}, error) {
+	c.expression(b.Left)
+	c.expression(b.Right)
+
+	span := b.Operator.Span()
+	switch b.Operator.TokenType {
+	case token.GREATER:
+		c.emit(span, OP_GREATER)
+	case token.GREATER_EQUAL:
+		c.emit(span, OP_GREATER_EQUAL)
+	case token.LESS:
+		c.emit(span, OP_LESS)
+	case token.LESS_EQUAL:
+		c.emit(span, OP_LESS_EQUAL)
+	case token.MINUS:
+		c.emit(span, OP_SUBTRACT)
+	case token.PLUS:
+		c.emit(span, OP_ADD)
+	case token.SLASH:
+		c.emit(span, OP_DIVIDE)
+	case token.STAR:
+		c.emit(span, OP_MULTIPLY)
+	case token.BANG_EQUAL:
+		c.emit(span, OP_EQUAL)
+		c.emit(span, OP_NOT)
+	case token.EQUAL_EQUAL:
+		c.emit(span, OP_EQUAL)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitCallExpr(call *parser.Call) (interface{}, error) {
+	c.expression(call.Callee)
+	for _, argument := range call.Arguments {
+		c.expression(argument)
+	}
+	c.emit(call.Paren.Span(), OP_CALL, byte(len(call.Arguments)))
+	return nil, nil
+}
+
+func (c *Compiler) VisitGetExpr(g *parser.Get) (interface{}, error) {
+	c.expression(g.Object)
+	c.emit(g.Name.Span(), OP_GET_PROPERTY, short(c.identifierConstant(g.Name))...)
+	return nil, nil
+}
+
+func (c *Compiler) VisitGroupingExpr(g *parser.Grouping) (interface{}, error) {
+	c.expression(g.Expression)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexExpr(i *parser.Index) (interface{}, error) {
+	c.expression(i.Object)
+	c.expression(i.Index)
+	c.emit(i.Bracket.Span(), OP_GET_INDEX)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLambdaExpr(l *parser.Lambda) (interface{}, error) {
+	c.function(l.Function, FUNCTION)
+	return nil, nil
+}
+
+func (c *Compiler) VisitListExpr(l *parser.List) (interface{}, error) {
+	for _, element := range l.Elements {
+		c.expression(element)
+	}
+	c.emit(l.Bracket.Span(), OP_LIST, short(len(l.Elements))...)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLiteralExpr(l *parser.Literal) (interface{}, error) {
+	span := l.Span()
+	switch v := l.Value.(type) {
+	case nil, value.NilValue:
+		c.emit(span, OP_NIL)
+	case value.Bool:
+		if v {
+			c.emit(span, OP_TRUE)
+		} else {
+			c.emit(span, OP_FALSE)
+		}
+	default:
+		c.emit(span, OP_CONSTANT, short(c.makeConstant(span, v))...)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitLogicalExpr(l *parser.Logical) (interface{}, error) {
+	span := l.Operator.Span()
+	c.expression(l.Left)
+
+	if l.Operator.TokenType == token.OR {
+		elseJump := c.emitJump(span, OP_JUMP_IF_FALSE)
+		endJump := c.emitJump(span, OP_JUMP)
+		c.patchJump(span, elseJump)
+		c.emit(span, OP_POP)
+		c.expression(l.Right)
+		c.patchJump(span, endJump)
+		return nil, nil
+	}
+
+	endJump := c.emitJump(span, OP_JUMP_IF_FALSE)
+	c.emit(span, OP_POP)
+	c.expression(l.Right)
+	c.patchJump(span, endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitMapExpr(m *parser.Map) (interface{}, error) {
+	for k := range m.Keys {
+		c.expression(m.Keys[k])
+		c.expression(m.Values[k])
+	}
+	c.emit(m.Brace.Span(), OP_MAP, short(len(m.Keys))...)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSetExpr(s *parser.Set) (interface{}, error) {
+	c.expression(s.Object)
+	c.expression(s.Value)
+	c.emit(s.Name.Span(), OP_SET_PROPERTY, short(c.identifierConstant(s.Name))...)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSetIndexExpr(s *parser.SetIndex) (interface{}, error) {
+	c.expression(s.Object)
+	c.expression(s.Index)
+	c.expression(s.Value)
+	c.emit(s.Bracket.Span(), OP_SET_INDEX)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSuperExpr(s *parser.Super) (interface{}, error) {
+	c.getVariable(token.NewToken(token.THIS, "this", nil, s.Keyword.Line, s.Keyword.Column))
+	c.getVariable(s.Keyword)
+	c.emit(s.Method.Span(), OP_GET_SUPER, short(c.identifierConstant(s.Method))...)
+	return nil, nil
+}
+
+func (c *Compiler) VisitThisExpr(t *parser.This) (interface{}, error) {
+	c.getVariable(t.Keyword)
+	return nil, nil
+}
+
+func (c *Compiler) VisitUnaryExpr(u *parser.Unary) (interface{}, error) {
+	c.expression(u.Right)
+
+	span := u.Operator.Span()
+	switch u.Operator.TokenType {
+	case token.MINUS:
+		c.emit(span, OP_NEGATE)
+	case token.BANG:
+		c.emit(span, OP_NOT)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitVariableExpr(v *parser.Variable) (interface{}, error) {
+	c.getVariable(v.Name)
+	return nil, nil
+}
@@ -0,0 +1,130 @@
+package compiler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+)
+
+// locals Ignores the resolved depths, the compiler works them out itself
+type locals struct{}
+
+func (locals) Resolve(expr parser.Expr, depth, slot int) {}
+
+func compile(t *testing.T, source string) *Function {
+	t.Helper()
+	diag := diagnostics.NewCollector()
+
+	tokens := scanner.NewScanner(source, diag).ScanTokens()
+	stmts, err := parser.NewParser(tokens, diag).Parse()
+	if err != nil {
+		t.Fatalf("parse error in %q: %v", source, err)
+	}
+	resolver.NewResolver(locals{}, diag).Resolve(stmts)
+
+	script, err := NewCompiler(diag).Compile(stmts)
+	if err != nil {
+		t.Fatalf("compile error in %q: %v", source, err)
+	}
+	return script
+}
+
+func disassemble(t *testing.T, script *Function) string {
+	t.Helper()
+	out := &bytes.Buffer{}
+	if err := Disassemble(out, script); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestDisassemble(t *testing.T) {
+	got := disassemble(t, compile(t, "var a = 1;\nfun f(x) { return () => x + a; }\nprint f(2)();"))
+
+	for _, want := range []string{
+		"== <script> ==\n0000    1 OP_CONSTANT         0 '1'\n0003    | OP_DEFINE_GLOBAL    1 'a'\n",
+		"   2 OP_CLOSURE          2 <fn f>\n",
+		"   3 OP_GET_GLOBAL       3 'f'\n",
+		"== <fn f> ==\n0000    2 OP_CLOSURE          0 <fn anonymous>\n0003    |                     local 1\n",
+		"== <fn anonymous> ==\n0000    2 OP_GET_UPVALUE      0\n0002    | OP_GET_GLOBAL       0 'a'\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("listing does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	script := compile(t, `
+class A { init(x) { this.x = x; } get() { return this.x; } }
+class B < A { get() { return super.get() * 1.5; } }
+var m = {"k": [nil, true, false, -0.25]};
+for (v in m["k"]) { if (!v) continue; print v; }
+try { throw "e"; } catch (e) { print e; } finally { print B(2).get(); }
+`)
+
+	encoded := &bytes.Buffer{}
+	if err := Encode(encoded, script); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := Decode(bytes.NewReader(encoded.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := disassemble(t, decoded), disassemble(t, script); got != want {
+		t.Errorf("decoded listing differs\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := decoded.Chunk.Lines, script.Chunk.Lines; len(got) != len(want) || got[len(got)-1] != want[len(want)-1] {
+		t.Errorf("decoded line table differs")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	encoded := &bytes.Buffer{}
+	if err := Encode(encoded, compile(t, `fun f(a) { return a; } print f(1);`)); err != nil {
+		t.Fatal(err)
+	}
+	valid := encoded.Bytes()
+
+	// The code of the script starts after the version, name, arity, upvalue count and code length
+	codeStart := len(MAGIC) + 1 + 3 + 1
+	damaged := append([]byte{}, valid...)
+	damaged[codeStart+1] = 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not compiled", []byte("print 1;")},
+		{"other version", append([]byte(MAGIC), FORMAT_VERSION+1)},
+		{"truncated", valid[:len(valid)-3]},
+		{"trailing bytes", append(append([]byte{}, valid...), 0)},
+		{"constant out of range", damaged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(bytes.NewReader(tt.data)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+
+	// Decode has to return for any damage, running what it accepts is tested by glox
+	t.Run("mutations", func(t *testing.T) {
+		for k := range valid {
+			for _, flip := range []byte{0x01, 0x80, 0xff} {
+				mutated := append([]byte{}, valid...)
+				mutated[k] ^= flip
+				Decode(bytes.NewReader(mutated))
+			}
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+var opNames = [...]string{
+	OP_CONSTANT:      "OP_CONSTANT",
+	OP_NIL:           "OP_NIL",
+	OP_TRUE:          "OP_TRUE",
+	OP_FALSE:         "OP_FALSE",
+	OP_POP:           "OP_POP",
+	OP_GET_LOCAL:     "OP_GET_LOCAL",
+	OP_SET_LOCAL:     "OP_SET_LOCAL",
+	OP_GET_GLOBAL:    "OP_GET_GLOBAL",
+	OP_DEFINE_GLOBAL: "OP_DEFINE_GLOBAL",
+	OP_SET_GLOBAL:    "OP_SET_GLOBAL",
+	OP_GET_UPVALUE:   "OP_GET_UPVALUE",
+	OP_SET_UPVALUE:   "OP_SET_UPVALUE",
+	OP_GET_PROPERTY:  "OP_GET_PROPERTY",
+	OP_SET_PROPERTY:  "OP_SET_PROPERTY",
+	OP_GET_SUPER:     "OP_GET_SUPER",
+	OP_GET_INDEX:     "OP_GET_INDEX",
+	OP_SET_INDEX:     "OP_SET_INDEX",
+	OP_EQUAL:         "OP_EQUAL",
+	OP_GREATER:       "OP_GREATER",
+	OP_GREATER_EQUAL: "OP_GREATER_EQUAL",
+	OP_LESS:          "OP_LESS",
+	OP_LESS_EQUAL:    "OP_LESS_EQUAL",
+	OP_ADD:           "OP_ADD",
+	OP_SUBTRACT:      "OP_SUBTRACT",
+	OP_MULTIPLY:      "OP_MULTIPLY",
+	OP_DIVIDE:        "OP_DIVIDE",
+	OP_NOT:           "OP_NOT",
+	OP_NEGATE:        "OP_NEGATE",
+	OP_PRINT:         "OP_PRINT",
+	OP_JUMP:          "OP_JUMP",
+	OP_JUMP_IF_FALSE: "OP_JUMP_IF_FALSE",
+	OP_LOOP:          "OP_LOOP",
+	OP_CALL:          "OP_CALL",
+	OP_CLOSURE:       "OP_CLOSURE",
+	OP_CLOSE_UPVALUE: "OP_CLOSE_UPVALUE",
+	OP_RETURN:        "OP_RETURN",
+	OP_CLASS:         "OP_CLASS",
+	OP_INHERIT:       "OP_INHERIT",
+	OP_METHOD:        "OP_METHOD",
+	OP_LIST:          "OP_LIST",
+	OP_MAP:           "OP_MAP",
+	OP_ITERATE:       "OP_ITERATE",
+	OP_FOR_NEXT:      "OP_FOR_NEXT",
+	OP_IMPORT:        "OP_IMPORT",
+	OP_THROW:         "OP_THROW",
+	OP_TRY:           "OP_TRY",
+	OP_END_TRY:       "OP_END_TRY",
+	OP_CATCH:         "OP_CATCH",
+	OP_RETHROW:       "OP_RETHROW",
+}
+
+func (op OpCode) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return fmt.Sprintf("OP_UNKNOWN(%d)", byte(op))
+}
+
+// Disassemble Writes the instructions of the function followed by
+// those of the functions it declares, in the format of clox's debug.c
+func Disassemble(w io.Writer, function *Function) error {
+	d := &disassembler{w: w}
+	d.function(function)
+	return d.err
+}
+
+// disassembler Keeps the first write error so the listing can be written without checks
+type disassembler struct {
+	w   io.Writer
+	err error
+}
+
+func (d *disassembler) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+func (d *disassembler) function(function *Function) {
+	d.printf("== %s ==\n", function.String())
+	chunk := function.Chunk
+	for offset := 0; offset < len(chunk.Code); {
+		offset = d.instruction(chunk, offset)
+	}
+
+	for _, constant := range chunk.Constants {
+		if nested, ok := constant.(*Function); ok {
+			d.printf("\n")
+			d.function(nested)
+		}
+	}
+}
+
+// instruction Writes the instruction at the offset and returns the offset of the next one
+func (d *disassembler) instruction(chunk *Chunk, offset int) int {
+	d.printf("%04d ", offset)
+	if offset > 0 && chunk.LineAt(offset) == chunk.LineAt(offset-1) {
+		d.printf("   | ")
+	} else {
+		d.printf("%4d ", chunk.LineAt(offset))
+	}
+
+	op := OpCode(chunk.Code[offset])
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+		OP_GET_PROPERTY, OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD, OP_IMPORT:
+		return d.constantInstruction(op, chunk, offset)
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL:
+		return d.byteInstruction(op, chunk, offset)
+	case OP_LIST, OP_MAP:
+		d.printf("%-16s %4d\n", op, readShort(chunk, offset+1))
+		return offset + 3
+	case OP_JUMP, OP_JUMP_IF_FALSE, OP_FOR_NEXT, OP_TRY:
+		return d.jumpInstruction(op, 1, chunk, offset)
+	case OP_LOOP:
+		return d.jumpInstruction(op, -1, chunk, offset)
+	case OP_CLOSURE:
+		return d.closureInstruction(chunk, offset)
+	}
+
+	d.printf("%s\n", op)
+	return offset + 1
+}
+
+func (d *disassembler) constantInstruction(op OpCode, chunk *Chunk, offset int) int {
+	constant := readShort(chunk, offset+1)
+	d.printf("%-16s %4d '%s'\n", op, constant, value.ToString(chunk.Constants[constant]))
+	return offset + 3
+}
+
+func (d *disassembler) byteInstruction(op OpCode, chunk *Chunk, offset int) int {
+	d.printf("%-16s %4d\n", op, chunk.Code[offset+1])
+	return offset + 2
+}
+
+func (d *disassembler) jumpInstruction(op OpCode, sign int, chunk *Chunk, offset int) int {
+	jump := readShort(chunk, offset+1)
+	d.printf("%-16s %4d -> %d\n", op, offset, offset+3+sign*jump)
+	return offset + 3
+}
+
+// closureInstruction Writes the function followed by a line for each variable it captures
+func (d *disassembler) closureInstruction(chunk *Chunk, offset int) int {
+	constant := readShort(chunk, offset+1)
+	function := chunk.Constants[constant].(*Function)
+	d.printf("%-16s %4d %s\n", OP_CLOSURE, constant, function.String())
+
+	offset += 3
+	for k := 0; k < function.UpvalueCount; k++ {
+		kind := "upvalue"
+		if chunk.Code[offset] == 1 {
+			kind = "local"
+		}
+		d.printf("%04d    |                     %s %d\n", offset, kind, chunk.Code[offset+1])
+		offset += 2
+	}
+	return offset
+}
+
+func readShort(chunk *Chunk, offset int) int {
+	return int(chunk.Code[offset])<<8 | int(chunk.Code[offset+1])
+}
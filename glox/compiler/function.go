@@ -0,0 +1,32 @@
+package compiler
+
+import "github.com/madraceee/interpreters/glox/value"
+
+// Function A compiled function. The script and every module
+// body are compiled into a function without parameters
+type Function struct {
+	// Empty for a script or module body
+	Name         string
+	Arity        int
+	UpvalueCount int
+	Chunk        *Chunk
+}
+
+func NewFunction(name string, arity int) *Function {
+	return &Function{
+		Name:  name,
+		Arity: arity,
+		Chunk: NewChunk(),
+	}
+}
+
+func (f *Function) Type() value.Type {
+	return value.CALLABLE_TYPE
+}
+
+func (f *Function) String() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return "<fn " + f.Name + ">"
+}
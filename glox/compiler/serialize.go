@@ -0,0 +1,425 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// MAGIC Starts every compiled file
+const MAGIC = "LOXC"
+
+// FORMAT_VERSION Changes whenever the encoding or the instruction set changes.
+// Files of another version have to be compiled again
+const FORMAT_VERSION = 1
+
+// Tags of the constants in a compiled file
+const (
+	TAG_NIL byte = iota
+	TAG_FALSE
+	TAG_TRUE
+	TAG_NUMBER
+	TAG_STRING
+	TAG_FUNCTION
+)
+
+var ErrNotCompiled = errors.New("Not a compiled Lox file.")
+
+// Encode Writes the script in the compiled file format.
+// Integers are unsigned varints and numbers their IEEE 754 bits
+//
+//	file     = MAGIC version function
+//	function = name arity upvalueCount code constants lines
+//	code     = length byte*
+//	constant = tag (number | string | function)?
+//	line     = offset span
+func Encode(w io.Writer, script *Function) error {
+	e := &encoder{}
+	e.buf.WriteString(MAGIC)
+	e.uint(FORMAT_VERSION)
+	if err := e.function(script); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) uint(n int) {
+	e.buf.Write(binary.AppendUvarint(nil, uint64(n)))
+}
+
+func (e *encoder) string(s string) {
+	e.uint(len(s))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) position(p token.Position) {
+	e.uint(p.Offset)
+	e.uint(p.Line)
+	e.uint(p.Column)
+}
+
+func (e *encoder) function(function *Function) error {
+	e.string(function.Name)
+	e.uint(function.Arity)
+	e.uint(function.UpvalueCount)
+
+	chunk := function.Chunk
+	e.uint(len(chunk.Code))
+	e.buf.Write(chunk.Code)
+
+	e.uint(len(chunk.Constants))
+	for _, constant := range chunk.Constants {
+		if err := e.constant(constant); err != nil {
+			return err
+		}
+	}
+
+	e.uint(len(chunk.Lines))
+	for _, line := range chunk.Lines {
+		e.uint(line.Offset)
+		e.position(line.Span.Start)
+		e.position(line.Span.End)
+	}
+	return nil
+}
+
+func (e *encoder) constant(constant value.Value) error {
+	switch c := constant.(type) {
+	case value.NilValue:
+		e.buf.WriteByte(TAG_NIL)
+	case value.Bool:
+		if c {
+			e.buf.WriteByte(TAG_TRUE)
+		} else {
+			e.buf.WriteByte(TAG_FALSE)
+		}
+	case value.Number:
+		e.buf.WriteByte(TAG_NUMBER)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(float64(c))))
+	case value.String:
+		e.buf.WriteByte(TAG_STRING)
+		e.string(string(c))
+	case *Function:
+		e.buf.WriteByte(TAG_FUNCTION)
+		return e.function(c)
+	default:
+		return fmt.Errorf("Can't encode constant %s.", value.ToString(constant))
+	}
+	return nil
+}
+
+// Decode Reads a script written by Encode. Every reachable instruction is
+// checked: its opcode is known, its operands are in range, jumps land on an
+// instruction and the stack never runs out, so accepted code is safe to run
+func Decode(r io.Reader) (*Function, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte(MAGIC)) {
+		return nil, ErrNotCompiled
+	}
+
+	d := &decoder{data: data, offset: len(MAGIC)}
+	if version := d.uint(); d.err == nil && version != FORMAT_VERSION {
+		return nil, fmt.Errorf("Compiled with format version %d, expected %d. Compile the script again.", version, FORMAT_VERSION)
+	}
+	script := d.function()
+	if d.err == nil && d.offset != len(d.data) {
+		d.fail()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return script, nil
+}
+
+// decoder Keeps the first error so the fields can be read without checks
+type decoder struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("Damaged compiled file at byte %d.", d.offset)
+	}
+}
+
+func (d *decoder) uint() int {
+	if d.err != nil {
+		return 0
+	}
+	n, size := binary.Uvarint(d.data[d.offset:])
+	if size <= 0 || n > math.MaxInt32 {
+		d.fail()
+		return 0
+	}
+	d.offset += size
+	return int(n)
+}
+
+// bytes Returns the next length bytes
+func (d *decoder) bytes(length int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if length > len(d.data)-d.offset {
+		d.fail()
+		return nil
+	}
+	b := d.data[d.offset : d.offset+length]
+	d.offset += length
+	return b
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(d.uint()))
+}
+
+func (d *decoder) position() token.Position {
+	return token.Position{Offset: d.uint(), Line: d.uint(), Column: d.uint()}
+}
+
+func (d *decoder) function() *Function {
+	function := NewFunction(d.string(), d.uint())
+	function.UpvalueCount = d.uint()
+
+	chunk := function.Chunk
+	chunk.Code = append(chunk.Code, d.bytes(d.uint())...)
+
+	count := d.uint()
+	for k := 0; k < count && d.err == nil; k++ {
+		chunk.Constants = append(chunk.Constants, d.constant())
+	}
+
+	count = d.uint()
+	for k := 0; k < count && d.err == nil; k++ {
+		offset := d.uint()
+		chunk.Lines = append(chunk.Lines, LineStart{
+			Offset: offset,
+			Span:   token.Span{Start: d.position(), End: d.position()},
+		})
+	}
+
+	if d.err == nil && !verify(function) {
+		d.err = fmt.Errorf("Damaged compiled function %s.", function.String())
+	}
+	return function
+}
+
+func (d *decoder) constant() value.Value {
+	tag := d.bytes(1)
+	if tag == nil {
+		return value.Nil
+	}
+
+	switch tag[0] {
+	case TAG_NIL:
+		return value.Nil
+	case TAG_FALSE:
+		return value.Bool(false)
+	case TAG_TRUE:
+		return value.Bool(true)
+	case TAG_NUMBER:
+		if bits := d.bytes(8); bits != nil {
+			return value.Number(math.Float64frombits(binary.BigEndian.Uint64(bits)))
+		}
+		return value.Nil
+	case TAG_STRING:
+		return value.String(d.string())
+	case TAG_FUNCTION:
+		return d.function()
+	}
+
+	d.fail()
+	return value.Nil
+}
+
+// state What verify knows before an instruction runs: the values on the
+// stack of the frame, the callee slot included, and the trys entered
+type state struct {
+	depth int
+	tries int
+}
+
+// verify Returns true if the function can't break the VM. Every path through
+// the code is followed to check that instructions are known, operands refer to
+// constants of the right kind, locals and upvalues which exist, jumps land on
+// instructions, the stack never runs out and no path runs past the end
+func verify(function *Function) bool {
+	chunk := function.Chunk
+	code := chunk.Code
+	if len(code) == 0 {
+		return false
+	}
+
+	// Where the instructions start, so jumps into operands are caught
+	starts := make([]bool, len(code))
+	for offset := 0; offset < len(code); {
+		starts[offset] = true
+		size := instructionSize(chunk, offset)
+		if size == 0 {
+			return false
+		}
+		offset += size
+	}
+
+	states := make([]*state, len(code))
+	states[0] = &state{depth: 1 + function.Arity}
+	pending := []int{0}
+	// next Records the state at the instruction the code continues at
+	next := func(offset int, s state) bool {
+		if offset < 0 || offset >= len(code) || !starts[offset] || s.depth < 1 || s.tries < 0 {
+			return false
+		}
+		if states[offset] == nil {
+			states[offset] = &s
+			pending = append(pending, offset)
+			return true
+		}
+		return *states[offset] == s
+	}
+
+	for len(pending) > 0 {
+		offset := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		s := *states[offset]
+		op := OpCode(code[offset])
+		after := offset + instructionSize(chunk, offset)
+
+		// needs Returns true if the stack holds n values above the callee slot
+		needs := func(n int) bool { return s.depth-1 >= n }
+		ok := true
+		switch op {
+		case OP_CONSTANT, OP_NIL, OP_TRUE, OP_FALSE, OP_GET_GLOBAL, OP_CLASS, OP_IMPORT:
+			s.depth++
+		case OP_POP, OP_DEFINE_GLOBAL, OP_PRINT, OP_CLOSE_UPVALUE:
+			ok = needs(1)
+			s.depth--
+		case OP_SET_GLOBAL, OP_GET_PROPERTY, OP_NOT, OP_NEGATE, OP_ITERATE, OP_CATCH:
+			ok = needs(1)
+		case OP_GET_LOCAL:
+			ok = int(code[offset+1]) < s.depth
+			s.depth++
+		case OP_SET_LOCAL:
+			ok = needs(1) && int(code[offset+1]) < s.depth
+		case OP_GET_UPVALUE:
+			ok = int(code[offset+1]) < function.UpvalueCount
+			s.depth++
+		case OP_SET_UPVALUE:
+			ok = needs(1) && int(code[offset+1]) < function.UpvalueCount
+		case OP_SET_PROPERTY, OP_GET_SUPER, OP_GET_INDEX, OP_EQUAL, OP_GREATER, OP_GREATER_EQUAL,
+			OP_LESS, OP_LESS_EQUAL, OP_ADD, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_INHERIT, OP_METHOD:
+			ok = needs(2)
+			s.depth--
+		case OP_SET_INDEX:
+			ok = needs(3)
+			s.depth -= 2
+		case OP_CALL:
+			argCount := int(code[offset+1])
+			ok = needs(argCount + 1)
+			s.depth -= argCount
+		case OP_LIST:
+			count := readShort(chunk, offset+1)
+			ok = needs(count)
+			s.depth += 1 - count
+		case OP_MAP:
+			count := readShort(chunk, offset+1)
+			ok = needs(2 * count)
+			s.depth += 1 - 2*count
+		case OP_CLOSURE:
+			nested := chunk.Constants[readShort(chunk, offset+1)].(*Function)
+			// A function which calls itself captures the slot the closure is pushed to
+			for k := 0; k < nested.UpvalueCount && ok; k++ {
+				isLocal, index := code[offset+3+2*k], int(code[offset+4+2*k])
+				ok = (isLocal == 1 && index <= s.depth) || (isLocal == 0 && index < function.UpvalueCount)
+			}
+			s.depth++
+		case OP_JUMP:
+			if !next(after+readShort(chunk, offset+1), s) {
+				return false
+			}
+			continue
+		case OP_LOOP:
+			if !next(after-readShort(chunk, offset+1), s) {
+				return false
+			}
+			continue
+		case OP_JUMP_IF_FALSE:
+			ok = needs(1) && next(after+readShort(chunk, offset+1), s)
+		case OP_FOR_NEXT:
+			// Jumps once the iterator is done, else pushes the next value
+			ok = needs(1) && next(after+readShort(chunk, offset+1), s)
+			s.depth++
+		case OP_TRY:
+			// The handler continues with the stack of the try and the error pushed
+			ok = next(after+readShort(chunk, offset+1), state{depth: s.depth + 1, tries: s.tries})
+			s.tries++
+		case OP_END_TRY:
+			s.tries--
+		case OP_RETURN, OP_THROW, OP_RETHROW:
+			if !needs(1) {
+				return false
+			}
+			continue
+		}
+		if !ok || !next(after, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// instructionSize Returns the size of the instruction at the offset with its
+// operands, or 0 if it is unknown, runs past the code or refers to a constant
+// which does not exist or is of the wrong kind
+func instructionSize(chunk *Chunk, offset int) int {
+	code := chunk.Code
+	op := OpCode(code[offset])
+	size := 1
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL,
+		OP_GET_PROPERTY, OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD, OP_IMPORT:
+		if offset+3 > len(code) || readShort(chunk, offset+1) >= len(chunk.Constants) {
+			return 0
+		}
+		if _, ok := value.AsString(chunk.Constants[readShort(chunk, offset+1)]); !ok && op != OP_CONSTANT {
+			return 0
+		}
+		size = 3
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL:
+		size = 2
+	case OP_LIST, OP_MAP, OP_JUMP, OP_JUMP_IF_FALSE, OP_FOR_NEXT, OP_TRY, OP_LOOP:
+		size = 3
+	case OP_CLOSURE:
+		if offset+3 > len(code) || readShort(chunk, offset+1) >= len(chunk.Constants) {
+			return 0
+		}
+		nested, ok := chunk.Constants[readShort(chunk, offset+1)].(*Function)
+		if !ok {
+			return 0
+		}
+		size = 3 + 2*nested.UpvalueCount
+	default:
+		if int(op) >= len(opNames) {
+			return 0
+		}
+	}
+	if offset+size > len(code) {
+		return 0
+	}
+	return size
+}
@@ -0,0 +1,193 @@
+package glox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/utils"
+)
+
+// Annotations of the Crafting Interpreters test suite. Static errors are
+// expected on the line of the comment unless it names another line
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)`)
+	expectError        = regexp.MustCompile(`// (?:\[[Ll]ine (\d+)\] )?(Error.*)`)
+)
+
+// expectations What a golden file has to print and the errors it has to stop with
+type expectations struct {
+	output []string
+	// "[Line N] message" of the runtime error, if one is expected
+	runtimeError string
+	// "[Line N] Error..." of each static error
+	errors []string
+}
+
+func parseExpectations(source string) expectations {
+	expected := expectations{}
+	for k, line := range strings.Split(source, "\n") {
+		at := "[Line " + strconv.Itoa(k+1) + "] "
+		if match := expectOutput.FindStringSubmatch(line); match != nil {
+			expected.output = append(expected.output, match[1])
+		} else if match := expectRuntimeError.FindStringSubmatch(line); match != nil {
+			expected.runtimeError = at + match[1]
+		} else if match := expectError.FindStringSubmatch(line); match != nil {
+			if match[1] != "" {
+				at = "[Line " + match[1] + "] "
+			}
+			expected.errors = append(expected.errors, at+match[2])
+		}
+	}
+	return expected
+}
+
+// runGolden Runs the file on the engine and returns the differences
+// from what its annotations expect
+func runGolden(file string, engine Engine) ([]string, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	expected := parseExpectations(string(source))
+
+	out := &bytes.Buffer{}
+	vm := New(Options{Stdout: out, FileName: file, Engine: engine})
+	err = vm.Eval(context.Background(), string(source))
+
+	failures := make([]string, 0)
+	output := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if out.Len() == 0 {
+		output = nil
+	}
+	for k := 0; k < max(len(output), len(expected.output)); k++ {
+		switch {
+		case k >= len(output):
+			failures = append(failures, "missing output "+strconv.Quote(expected.output[k]))
+		case k >= len(expected.output):
+			failures = append(failures, "unexpected output "+strconv.Quote(output[k]))
+		case output[k] != expected.output[k]:
+			failures = append(failures, "output "+strconv.Quote(output[k])+", expected "+strconv.Quote(expected.output[k]))
+		}
+	}
+
+	runtimeError, staticErrors := "", []string{}
+	var runtime *utils.RuntimeError
+	var gloxError *Error
+	if errors.As(err, &runtime) {
+		runtimeError = "[Line " + strconv.Itoa(runtime.Token.Line) + "] " + runtime.Err.Error()
+	} else if errors.As(err, &gloxError) {
+		for _, d := range gloxError.Diagnostics {
+			if d.Code != diagnostics.RUNTIME_ERROR {
+				staticErrors = append(staticErrors, staticError(d, string(source)))
+			}
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if runtimeError != expected.runtimeError {
+		failures = append(failures, "runtime error "+strconv.Quote(runtimeError)+", expected "+strconv.Quote(expected.runtimeError))
+	}
+	if strings.Join(staticErrors, "\n") != strings.Join(expected.errors, "\n") {
+		failures = append(failures, "errors "+strconv.Quote(strings.Join(staticErrors, "\n"))+", expected "+strconv.Quote(strings.Join(expected.errors, "\n")))
+	}
+	return failures, nil
+}
+
+// staticError Returns the first line of the rendered diagnostic without the file
+func staticError(d diagnostics.Diagnostic, source string) string {
+	d.File = ""
+	line, _, _ := strings.Cut(d.Render(source), "\n")
+	return line
+}
+
+// goldenFiles Returns the .lox files under testdata which have annotations.
+// Files without any are modules imported by the others
+func goldenFiles(t *testing.T) []string {
+	t.Helper()
+	files := make([]string, 0)
+	err := filepath.WalkDir("testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(source), "// expect") || expectError.Match(source) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestConformance(t *testing.T) {
+	files := goldenFiles(t)
+	engines := []struct {
+		name   string
+		engine Engine
+	}{
+		{"tree-walker", TREE_WALKER},
+		{"vm", BYTECODE},
+	}
+
+	for _, e := range engines {
+		t.Run(e.name, func(t *testing.T) {
+			passed := 0
+			for _, file := range files {
+				name := strings.TrimSuffix(filepath.ToSlash(strings.TrimPrefix(file, "testdata"+string(filepath.Separator))), ".lox")
+				ok := t.Run(name, func(t *testing.T) {
+					failures, err := runGolden(file, e.engine)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, failure := range failures {
+						t.Error(failure)
+					}
+				})
+				if ok {
+					passed++
+				}
+			}
+			t.Logf("%d of %d files pass", passed, len(files))
+		})
+	}
+}
+
+// TestGoldenFilesDecode Checks that Decode accepts what the compiler produces
+// for every golden file which compiles, so its checks never reject valid code
+func TestGoldenFilesDecode(t *testing.T) {
+	for _, file := range goldenFiles(t) {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		script, err := New(Options{FileName: file}).Compile(string(source))
+		if err != nil {
+			continue
+		}
+
+		encoded := &bytes.Buffer{}
+		if err := compiler.Encode(encoded, script); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if _, err := compiler.Decode(encoded); err != nil {
+			t.Errorf("%s: %v", file, err)
+		}
+	}
+}
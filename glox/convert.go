@@ -0,0 +1,176 @@
+package glox
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Func A Go function which can be set as a Lox global and called from Lox
+type Func func(args ...interface{}) (interface{}, error)
+
+// ToValue Converts a Go value into a Lox value.
+// nil, booleans, numbers and strings map to the Lox value of the same kind,
+// slices to lists, maps with string keys to maps and Func to a native function.
+// Lox values are kept as they are. A slice or map which contains itself is an error
+func ToValue(v interface{}) (value.Value, error) {
+	return toValue(v, make(map[container]bool))
+}
+
+// container Identifies a Go slice or map, slices by their elements and length
+type container struct {
+	kind    reflect.Kind
+	pointer uintptr
+	length  int
+}
+
+// toValue Converts the value, converting holds the slices and maps it is inside of
+func toValue(v interface{}, converting map[container]bool) (value.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return value.Nil, nil
+	case value.Value:
+		return val, nil
+	case bool:
+		return value.Bool(val), nil
+	case string:
+		return value.String(val), nil
+	case Func:
+		return toNative("anonymous", val), nil
+	case func(args ...interface{}) (interface{}, error):
+		return toNative("anonymous", val), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map) && rv.Len() > 0 {
+		key := container{rv.Kind(), rv.Pointer(), rv.Len()}
+		if converting[key] {
+			return nil, fmt.Errorf("can't convert %T which contains itself to a Lox value", v)
+		}
+		converting[key] = true
+		defer delete(converting, key)
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Number(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Number(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Number(rv.Float()), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]value.Value, 0, rv.Len())
+		for k := 0; k < rv.Len(); k++ {
+			element, err := toValue(rv.Index(k).Interface(), converting)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		return value.NewList(elements), nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		// Sorted so the map has the same order every time
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(a, b int) bool { return keys[a].String() < keys[b].String() })
+
+		m := value.NewMap()
+		for _, key := range keys {
+			val, err := toValue(rv.MapIndex(key).Interface(), converting)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(value.String(key.String()), val)
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("can't convert %T to a Lox value", v)
+}
+
+// FromValue Converts a Lox value into a Go value.
+// nil, booleans, numbers and strings map to nil, bool, float64 and string,
+// lists to []interface{} and maps to map[string]interface{} keyed by the
+// text form of the keys. Functions, classes and instances are returned as
+// they are so they can be passed back to Lox. So is a list or map where it
+// repeats inside itself, which has no Go form
+func FromValue(v value.Value) interface{} {
+	return fromValue(v, make(map[value.Value]bool))
+}
+
+// fromValue Converts the value, converting holds the lists and maps it is inside of
+func fromValue(v value.Value, converting map[value.Value]bool) interface{} {
+	switch val := v.(type) {
+	case nil, value.NilValue:
+		return nil
+	case value.Bool:
+		return bool(val)
+	case value.Number:
+		return float64(val)
+	case value.String:
+		return string(val)
+	case *value.List:
+		if converting[val] {
+			return val
+		}
+		converting[val] = true
+		defer delete(converting, val)
+
+		elements := make([]interface{}, 0, len(val.Elements))
+		for _, element := range val.Elements {
+			elements = append(elements, fromValue(element, converting))
+		}
+		return elements
+	case *value.Map:
+		if converting[val] {
+			return val
+		}
+		converting[val] = true
+		defer delete(converting, val)
+
+		m := make(map[string]interface{}, val.Len())
+		for _, key := range val.Keys() {
+			entry, _ := val.Get(key)
+			m[value.ToString(key)] = fromValue(entry, converting)
+		}
+		return m
+	}
+	return v
+}
+
+func toValues(args []interface{}) ([]value.Value, error) {
+	values := make([]value.Value, 0, len(args))
+	for _, arg := range args {
+		val, err := ToValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// toNative Wraps the Go function so Lox can call it with any number of arguments
+func toNative(name string, fn Func) value.Value {
+	return &stdlib.NativeFunction{
+		Name:  name,
+		Arity: stdlib.VARIADIC,
+		Fn: func(arguments []value.Value) (value.Value, error) {
+			args := make([]interface{}, 0, len(arguments))
+			for _, argument := range arguments {
+				args = append(args, FromValue(argument))
+			}
+
+			result, err := fn(args...)
+			if err != nil {
+				return nil, err
+			}
+			return ToValue(result)
+		},
+	}
+}
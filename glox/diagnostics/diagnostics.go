@@ -0,0 +1,209 @@
+package diagnostics
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/madraceee/interpreters/glox/token"
+)
+
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+	NOTE
+)
+
+func (s Severity) String() string {
+	switch s {
+	case ERROR:
+		return "Error"
+	case WARNING:
+		return "Warning"
+	case NOTE:
+		return "Note"
+	}
+	return ""
+}
+
+// Code Identifies the phase which reported the diagnostic
+type Code string
+
+const (
+	SCAN_ERROR    Code = "scan"
+	PARSE_ERROR   Code = "parse"
+	RESOLVE_ERROR Code = "resolve"
+	COMPILE_ERROR Code = "compile"
+	RUNTIME_ERROR Code = "runtime"
+)
+
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Message  string
+	Span     token.Span
+	Notes    []string
+	// File the span points into, empty for the file being run
+	File string
+}
+
+func (d Diagnostic) Error() string {
+	return "[Line " + strconv.Itoa(d.Span.Start.Line) + "] " + d.Severity.String() + ": " + d.Message
+}
+
+// Render Returns the diagnostic with the part of the source it points to
+func (d Diagnostic) Render(source string) string {
+	builder := strings.Builder{}
+	if d.File != "" {
+		builder.WriteString(d.File + ": ")
+	}
+	builder.WriteString("[Line " + strconv.Itoa(d.Span.Start.Line) + "] ")
+	if d.Code == RUNTIME_ERROR {
+		builder.WriteString("Runtime ")
+	}
+	builder.WriteString(d.Severity.String())
+	if where := d.where(source); where != "" {
+		builder.WriteString(" " + where)
+	}
+	builder.WriteString(": " + d.Message + "\n")
+	builder.WriteString(Excerpt(source, d.Span))
+	for _, note := range d.Notes {
+		builder.WriteString("  " + note + "\n")
+	}
+	return builder.String()
+}
+
+// where Returns the text the static error was reported at
+func (d Diagnostic) where(source string) string {
+	if d.Code != PARSE_ERROR && d.Code != RESOLVE_ERROR && d.Code != COMPILE_ERROR {
+		return ""
+	}
+
+	start, end := d.Span.Start.Offset, d.Span.End.Offset
+	if start >= len(source) {
+		return "at end"
+	}
+	if start < end && end <= len(source) {
+		text, _, _ := strings.Cut(source[start:end], "\n")
+		return "at '" + text + "'"
+	}
+	return ""
+}
+
+// List All the diagnostics reported by a phase, usable as an error
+type List []Diagnostic
+
+func (l List) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, d := range l {
+		messages = append(messages, d.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Collector Gathers diagnostics from the scanner, parser, resolver and interpreter
+type Collector struct {
+	diagnostics List
+	// Sources of the imported files, by file name
+	sources map[string]string
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		diagnostics: make(List, 0),
+		sources:     make(map[string]string),
+	}
+}
+
+// AddSource Registers the source of an imported file so
+// diagnostics in it are rendered with the right excerpt
+func (c *Collector) AddSource(file, source string) {
+	c.sources[file] = source
+}
+
+func (c *Collector) Report(d Diagnostic) {
+	c.diagnostics = append(c.diagnostics, d)
+}
+
+// Error Reports an error of the phase at the span
+func (c *Collector) Error(code Code, span token.Span, message string) Diagnostic {
+	d := Diagnostic{
+		Severity: ERROR,
+		Code:     code,
+		Message:  message,
+		Span:     span,
+	}
+	c.Report(d)
+	return d
+}
+
+func (c *Collector) HasErrors() bool {
+	for _, d := range c.diagnostics {
+		if d.Severity == ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Collector) Diagnostics() List {
+	return c.diagnostics
+}
+
+// Reset Removes all the reported diagnostics
+func (c *Collector) Reset() {
+	c.diagnostics = make(List, 0)
+}
+
+// Render Writes every diagnostic along with the source it points to.
+// source is the file being run, imported files use their registered source
+func (c *Collector) Render(w io.Writer, source string) error {
+	for _, d := range c.diagnostics {
+		text := source
+		if fileSource, ok := c.sources[d.File]; ok {
+			text = fileSource
+		}
+		if _, err := io.WriteString(w, d.Render(text)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Excerpt Returns the source line of the span with the span underlined.
+// Spans over multiple lines are underlined till the end of the first line.
+// Nothing is returned without the source, as for compiled scripts
+func Excerpt(source string, span token.Span) string {
+	if source == "" {
+		return ""
+	}
+	lines := strings.Split(source, "\n")
+	if span.Start.Line < 1 || span.Start.Line > len(lines) || span.Start.Column < 1 {
+		return ""
+	}
+
+	line := strings.TrimRight(lines[span.Start.Line-1], "\r")
+	start := min(span.Start.Column-1, len(line))
+	end := len(line)
+	if span.End.Line == span.Start.Line {
+		end = min(span.End.Column-1, len(line))
+	}
+
+	// Tabs are kept so the carets line up with the source
+	underline := strings.Builder{}
+	for _, c := range line[:start] {
+		if c == '\t' {
+			underline.WriteRune('\t')
+		} else {
+			underline.WriteRune(' ')
+		}
+	}
+	underline.WriteString(strings.Repeat("^", max(end-start, 1)))
+
+	lineNumber := strconv.Itoa(span.Start.Line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+	return " " + lineNumber + " | " + line + "\n" +
+		" " + gutter + " | " + underline.String() + "\n"
+}
@@ -0,0 +1,170 @@
+// Package difftest Runs Lox programs on glox and on clox, the C
+// implementation next to it, and reports where the two disagree
+package difftest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/madraceee/interpreters/glox"
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/utils"
+)
+
+// Exit codes of clox, glox's outcomes are mapped onto them
+const (
+	EXIT_OK            = 0
+	EXIT_COMPILE_ERROR = 65
+	EXIT_RUNTIME_ERROR = 70
+)
+
+// TIMEOUT How long clox may run a program before it is killed
+const TIMEOUT = 10 * time.Second
+
+// CLOX_SOURCES Files copied to build clox, relative to the clox directory
+var CLOX_SOURCES = []string{"Makefile", "*.c", "*.h"}
+
+// Outcome What running a program did
+type Outcome struct {
+	Stdout   string
+	ExitCode int
+	// Line of each compile error, or of the runtime error
+	ErrorLines []int
+	// Messages of the errors, only shown to explain a divergence since
+	// the two implementations word them differently
+	Errors []string
+}
+
+// BuildClox Copies the clox sources into dir and builds them with its
+// Makefile, leaving the source tree untouched. Returns the binary
+func BuildClox(source, dir string) (string, error) {
+	for _, pattern := range CLOX_SOURCES {
+		files, err := filepath.Glob(filepath.Join(source, pattern))
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return "", err
+			}
+			if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0o644); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	cmd := exec.Command("make", "build")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("Can't build clox: %v\n%s", err, out)
+	}
+	return filepath.Join(dir, "clox"), nil
+}
+
+// cloxLine Matches the line clox reports an error at, both in compile
+// errors and in the frames of a runtime error
+var cloxLine = regexp.MustCompile(`^\[line (\d+)\]`)
+
+// RunClox Runs the file with the clox binary
+func RunClox(binary, file string) (Outcome, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, binary, file)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+
+	outcome := Outcome{Stdout: stdout.String()}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && ctx.Err() == nil {
+		outcome.ExitCode = exit.ExitCode()
+	} else if err != nil {
+		return outcome, fmt.Errorf("Can't run clox on %s: %v", file, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+	switch outcome.ExitCode {
+	case EXIT_COMPILE_ERROR:
+		// [line N] Error at 'x': message
+		for _, line := range lines {
+			if match := cloxLine.FindStringSubmatch(line); match != nil {
+				n, _ := strconv.Atoi(match[1])
+				outcome.ErrorLines = append(outcome.ErrorLines, n)
+				outcome.Errors = append(outcome.Errors, line)
+			}
+		}
+	case EXIT_RUNTIME_ERROR:
+		// The message followed by the frames, innermost first
+		outcome.Errors = append(outcome.Errors, lines[0])
+		for _, line := range lines[1:] {
+			if match := cloxLine.FindStringSubmatch(line); match != nil {
+				n, _ := strconv.Atoi(match[1])
+				outcome.ErrorLines = append(outcome.ErrorLines, n)
+				break
+			}
+		}
+	}
+	return outcome, nil
+}
+
+// RunGlox Runs the file with glox on the engine
+func RunGlox(file string, engine glox.Engine) (Outcome, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	out := &bytes.Buffer{}
+	vm := glox.New(glox.Options{Stdout: out, FileName: file, Engine: engine})
+	err = vm.Eval(context.Background(), string(source))
+
+	outcome := Outcome{Stdout: out.String()}
+	var runtime *utils.RuntimeError
+	var gloxError *glox.Error
+	if errors.As(err, &runtime) {
+		outcome.ExitCode = EXIT_RUNTIME_ERROR
+		outcome.ErrorLines = []int{runtime.Token.Line}
+		outcome.Errors = []string{runtime.Err.Error()}
+	} else if errors.As(err, &gloxError) {
+		outcome.ExitCode = EXIT_COMPILE_ERROR
+		for _, d := range gloxError.Diagnostics {
+			if d.Code != diagnostics.RUNTIME_ERROR {
+				outcome.ErrorLines = append(outcome.ErrorLines, d.Span.Start.Line)
+				outcome.Errors = append(outcome.Errors, d.Error())
+			}
+		}
+	} else if err != nil {
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// Diff Returns how the outcome of glox differs from that of clox.
+// Stdout, the exit code and the lines of the errors have to agree
+func Diff(glox, clox Outcome) []string {
+	divergences := make([]string, 0)
+	if glox.Stdout != clox.Stdout {
+		divergences = append(divergences, "stdout "+strconv.Quote(glox.Stdout)+", clox "+strconv.Quote(clox.Stdout))
+	}
+	if glox.ExitCode != clox.ExitCode {
+		divergences = append(divergences, fmt.Sprintf("exit code %d, clox %d", glox.ExitCode, clox.ExitCode))
+	}
+	if fmt.Sprint(glox.ErrorLines) != fmt.Sprint(clox.ErrorLines) {
+		divergences = append(divergences, fmt.Sprintf("error lines %v, clox %v", glox.ErrorLines, clox.ErrorLines))
+	}
+	if len(divergences) > 0 && len(glox.Errors)+len(clox.Errors) > 0 {
+		divergences = append(divergences, "errors "+strconv.Quote(strings.Join(glox.Errors, "\n"))+", clox "+strconv.Quote(strings.Join(clox.Errors, "\n")))
+	}
+	return divergences
+}
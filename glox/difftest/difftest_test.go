@@ -0,0 +1,120 @@
+package difftest
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox"
+)
+
+// buildClox Builds clox once per test, skipping when there is no toolchain
+func buildClox(t *testing.T) string {
+	t.Helper()
+	for _, tool := range []string{"make", "gcc"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is needed to build clox", tool)
+		}
+	}
+	binary, err := BuildClox(filepath.Join("..", "..", "clox"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return binary
+}
+
+// knownDivergences Programs the two implementations are known to disagree
+// on, with the reason. clox has no comments, so they can't be marked in
+// the programs themselves. A divergence which is fixed fails the test
+// until it is removed from here
+var knownDivergences = map[string]string{
+	"comparison_operators": "clox does not parse !=, <= and >=",
+	"divide_by_zero":       "glox raises a runtime error, clox prints inf",
+	"missing_expression":   "clox skips the ';' and reports the error at the token after it",
+	"number_format":        "clox prints numbers with %g, glox prints the shortest exact form",
+	"parameters":           "clox reads parameters from the wrong stack slot",
+	"string_equality":      "clox does not intern strings, so equal strings compare false",
+}
+
+func TestGloxAgreesWithClox(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds clox")
+	}
+	binary := buildClox(t)
+	files, err := filepath.Glob(filepath.Join("testdata", "*.lox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engines := []struct {
+		name   string
+		engine glox.Engine
+	}{
+		{"tree-walker", glox.TREE_WALKER},
+		{"vm", glox.BYTECODE},
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".lox")
+		reason, known := knownDivergences[name]
+
+		t.Run(name, func(t *testing.T) {
+			clox, err := RunClox(binary, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range engines {
+				outcome, err := RunGlox(file, e.engine)
+				if err != nil {
+					t.Fatal(err)
+				}
+				divergences := Diff(outcome, clox)
+				switch {
+				case known && len(divergences) == 0:
+					t.Errorf("%s: no longer diverges, remove the annotation", e.name)
+				case known:
+					t.Logf("%s: known divergence, %s: %s", e.name, reason, strings.Join(divergences, "; "))
+				default:
+					for _, divergence := range divergences {
+						t.Errorf("%s: %s", e.name, divergence)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		glox  Outcome
+		clox  Outcome
+		count int
+	}{
+		{"same", Outcome{Stdout: "1\n"}, Outcome{Stdout: "1\n"}, 0},
+		{"stdout", Outcome{Stdout: "0.30000000000000004\n"}, Outcome{Stdout: "0.3\n"}, 1},
+		{"exit code", Outcome{}, Outcome{ExitCode: EXIT_RUNTIME_ERROR}, 1},
+		{
+			"messages only",
+			Outcome{ExitCode: EXIT_RUNTIME_ERROR, ErrorLines: []int{2}, Errors: []string{"Undefined variable 'x'"}},
+			Outcome{ExitCode: EXIT_RUNTIME_ERROR, ErrorLines: []int{2}, Errors: []string{"Undefined variable 'x'."}},
+			0,
+		},
+		{
+			"error line",
+			Outcome{ExitCode: EXIT_COMPILE_ERROR, ErrorLines: []int{1}, Errors: []string{"a"}},
+			Outcome{ExitCode: EXIT_COMPILE_ERROR, ErrorLines: []int{2}, Errors: []string{"b"}},
+			// The line and the messages explaining it
+			2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if divergences := Diff(test.glox, test.clox); len(divergences) != test.count {
+				t.Errorf("got %d divergences %q, expected %d", len(divergences), divergences, test.count)
+			}
+		})
+	}
+}
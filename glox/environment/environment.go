@@ -2,88 +2,105 @@ package environment
 
 import (
 	"errors"
-	"strconv"
 
 	"github.com/madraceee/interpreters/glox/token"
 	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
 )
 
+// Environment The variables of a scope. Locals are kept in the order they
+// are declared and found by the slot the resolver gave them. Globals are
+// late bound, as in the REPL, so they are kept by name
 type Environment struct {
 	Enclosing *Environment
-	value     map[string]interface{}
+	slots     []value.Value
+	// Only set for the globals of a module and the natives
+	globals map[string]value.Value
 }
 
-type RuntimeError struct {
-	Token token.Token
-	Err   error
-}
-
-func (e *RuntimeError) Error() string {
-	utils.HadRunTimeError = true
-	lineStr := strconv.Itoa(e.Token.Line)
-	return "Runtime Error: " + e.Err.Error() + "\n[Line " + lineStr + "]\n"
+// NewEnvironment Returns the environment of a local scope
+func NewEnvironment(env *Environment) *Environment {
+	return &Environment{
+		Enclosing: env,
+	}
 }
 
-func NewEnvironment(env *Environment) *Environment {
+// NewGlobals Returns an environment whose variables are looked up by name
+func NewGlobals(env *Environment) *Environment {
 	return &Environment{
 		Enclosing: env,
-		value:     make(map[string]interface{}),
+		globals:   make(map[string]value.Value),
 	}
 }
 
-func (e *Environment) Define(name string, obj interface{}) {
-	e.value[name] = obj
+// Define Adds the variable. Locals take the next slot
+func (e *Environment) Define(name string, obj value.Value) {
+	if e.globals != nil {
+		e.globals[name] = obj
+		return
+	}
+	e.slots = append(e.slots, obj)
 }
 
-func (e *Environment) Get(name token.Token) (interface{}, error) {
-	val, ok := e.value[name.Lexeme]
-	if !ok {
-		if e.Enclosing != nil {
-			envclosedVal, err := e.Enclosing.Get(name)
-			if envclosedVal == nil {
-				return nil, errors.New("Varible '" + name.Lexeme + "' not assigned at line " + strconv.Itoa(name.Line) + ".")
-			}
-			if err != nil {
-				return nil, err
-			}
-			return envclosedVal, nil
+// Get Returns the global with the name from this or an enclosing environment
+func (e *Environment) Get(name token.Token) (value.Value, error) {
+	for env := e; env != nil; env = env.Enclosing {
+		if val, ok := env.globals[name.Lexeme]; ok {
+			return val, nil
 		}
+	}
 
-		return nil, &RuntimeError{
-			Token: name,
-			Err:   errors.New("Undefined variable '" + name.Lexeme + "'"),
+	return nil, &utils.RuntimeError{
+		Token: name,
+		Err:   errors.New("Undefined variable '" + name.Lexeme + "'"),
+	}
+}
+
+// Assign Sets the global with the name in this or an enclosing environment
+func (e *Environment) Assign(name token.Token, value value.Value) error {
+	for env := e; env != nil; env = env.Enclosing {
+		if _, ok := env.globals[name.Lexeme]; ok {
+			env.globals[name.Lexeme] = value
+			return nil
 		}
 	}
-	if val == nil {
-		return nil, errors.New("Varible '" + name.Lexeme + "' not assigned at line " + strconv.Itoa(name.Line) + ".")
+
+	return &utils.RuntimeError{
+		Token: name,
+		Err:   errors.New("Undefined variable '" + name.Lexeme + "'."),
 	}
+}
 
-	return val, nil
+// Contains Returns the global with the name from this environment only
+func (e *Environment) Contains(name token.Token) (value.Value, bool) {
+	val, ok := e.globals[name.Lexeme]
+	return val, ok
 }
 
-func (e *Environment) Assign(name token.Token, value interface{}) error {
-	_, ok := e.Contains(name)
-	if ok {
-		e.Set(name, value)
-		return nil
+// Ancestor Returns the environment which is distance hops up the Enclosing chain
+func (e *Environment) Ancestor(distance int) *Environment {
+	env := e
+	for i := 0; i < distance; i++ {
+		env = env.Enclosing
 	}
 
-	if e.Enclosing != nil {
-		err := e.Enclosing.Assign(name, value)
-		return err
-	}
+	return env
+}
 
-	return &RuntimeError{
-		Token: name,
-		Err:   errors.New("Undefined variable '" + name.Lexeme + "'."),
+// GetAt Returns the local in the slot of the environment resolved by the resolver
+func (e *Environment) GetAt(distance, slot int, name token.Token) (value.Value, error) {
+	env := e.Ancestor(distance)
+	if slot >= len(env.slots) {
+		return nil, &utils.RuntimeError{
+			Token: name,
+			Err:   errors.New("Undefined variable '" + name.Lexeme + "'"),
+		}
 	}
-}
 
-func (e *Environment) Set(name token.Token, obj interface{}) {
-	e.value[name.Lexeme] = obj
+	return env.slots[slot], nil
 }
 
-func (e *Environment) Contains(name token.Token) (interface{}, bool) {
-	val, ok := e.value[name.Lexeme]
-	return val, ok
+// AssignAt Assigns to the local in the slot of the environment resolved by the resolver
+func (e *Environment) AssignAt(distance, slot int, value value.Value) {
+	e.Ancestor(distance).slots[slot] = value
 }
@@ -0,0 +1,291 @@
+// Package glox Runs Lox source from Go programs.
+//
+//	vm := glox.New(glox.Options{Stdout: &out})
+//	err := vm.Eval(ctx, `fun add(a, b) { return a + b; }`)
+//	sum, err := vm.Call("add", 1, 2)
+package glox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/interpreter"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+	"github.com/madraceee/interpreters/glox/vm"
+)
+
+// Options Configures a VM. The zero value uses the standard streams
+type Options struct {
+	// Where print and the io functions write to, os.Stdout if nil
+	Stdout io.Writer
+	// Where the io functions read from, os.Stdin if nil
+	Stdin io.Reader
+	// Name of the source shown in stack traces, imports are resolved relative to it
+	FileName string
+	// Directories searched for imported modules
+	SearchPath []string
+	// Bounds on the work done by each call to Eval or Call
+	Limits Limits
+	// What runs the source, the tree-walker if not set
+	Engine Engine
+}
+
+// Engine Selects how the source is run
+type Engine int
+
+const (
+	// TREE_WALKER Walks the syntax tree
+	TREE_WALKER Engine = iota
+	// BYTECODE Compiles the source to bytecode and runs it on a stack machine
+	BYTECODE
+)
+
+// engine What both the tree-walker and the bytecode VM do for a VM
+type engine interface {
+	resolver.Locals
+	SetIO(in io.Reader, out io.Writer)
+	SetFileName(fileName string)
+	SetSearchPath(paths []string)
+	SetLimits(limits interpreter.Limits)
+	SetContext(ctx context.Context)
+	Interpret(stmts []parser.Stmt) error
+	GetGlobal(name string) (value.Value, bool)
+	SetGlobal(name string, val value.Value)
+	Call(callee value.Value, arguments []value.Value) (value.Value, error)
+}
+
+// Limits Bounds the steps, call depth and string size of a script.
+// Zero means unlimited, except for the call depth which has a default
+type Limits = interpreter.Limits
+
+// Errors wrapped by the runtime error returned when a limit is hit
+var (
+	ErrStepLimit   = interpreter.ErrStepLimit
+	ErrStackLimit  = interpreter.ErrStackLimit
+	ErrStringLimit = interpreter.ErrStringLimit
+)
+
+// ErrNotBytecode Is returned when a compiled script is run by the tree-walker
+var ErrNotBytecode = errors.New("Compiled scripts can only be run by the bytecode engine.")
+
+// VM An interpreter whose globals are kept between calls to Eval
+type VM struct {
+	engine engine
+	diag   *diagnostics.Collector
+}
+
+func New(options Options) *VM {
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stdin == nil {
+		options.Stdin = os.Stdin
+	}
+
+	diag := diagnostics.NewCollector()
+	var e engine
+	switch options.Engine {
+	case BYTECODE:
+		e = vm.New(diag)
+	default:
+		e = interpreter.NewInterpreter(diag)
+	}
+	e.SetIO(options.Stdin, options.Stdout)
+	if options.FileName != "" {
+		e.SetFileName(options.FileName)
+	}
+	e.SetSearchPath(options.SearchPath)
+	e.SetLimits(options.Limits)
+
+	return &VM{
+		engine: e,
+		diag:   diag,
+	}
+}
+
+// Error Is returned when the source has static errors or raises a runtime error
+type Error struct {
+	Diagnostics diagnostics.List
+	// Diagnostics rendered with the source they point to
+	rendered string
+	// Runtime error, if the source could run
+	err error
+}
+
+func (e *Error) Error() string {
+	return strings.TrimSuffix(e.rendered, "\n")
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Eval Scans, parses, resolves and runs the source.
+// Globals it defines stay available to later calls.
+// The script stops with a runtime error once ctx is done
+func (vm *VM) Eval(ctx context.Context, source string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	vm.diag.Reset()
+	vm.engine.SetContext(ctx)
+
+	statements, err := vm.parse(source)
+	if err != nil {
+		return err
+	}
+
+	utils.DPrintf("%s\n", "----Interpreter----")
+	if err := vm.engine.Interpret(statements); err != nil {
+		return vm.error(source, err)
+	}
+	return nil
+}
+
+// parse Scans, parses and resolves the source
+func (vm *VM) parse(source string) ([]parser.Stmt, error) {
+	utils.DPrintf("%s\n", "----Scanning----")
+	tokens := scanner.NewScanner(source, vm.diag).ScanTokens()
+	for _, v := range tokens {
+		utils.DPrintf("%s\n", v)
+	}
+
+	utils.DPrintf("%s\n", "----Parsing----")
+	statements, err := parser.NewParser(tokens, vm.diag).Parse()
+	if err != nil || vm.diag.HasErrors() {
+		return nil, vm.error(source, nil)
+	}
+
+	utils.DPrintf("%s\n", "----Resolving----")
+	resolver.NewResolver(vm.engine, vm.diag).Resolve(statements)
+	if vm.diag.HasErrors() {
+		return nil, vm.error(source, nil)
+	}
+
+	return statements, nil
+}
+
+// Compile Compiles the source into bytecode which can be
+// disassembled, written to a compiled file or run with Run
+func (vm *VM) Compile(source string) (*compiler.Function, error) {
+	vm.diag.Reset()
+	statements, err := vm.parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := compiler.NewCompiler(vm.diag).Compile(statements)
+	if err != nil {
+		return nil, vm.error(source, nil)
+	}
+	return script, nil
+}
+
+// Run Runs the compiled script. Only the bytecode engine runs compiled scripts.
+// Runtime errors are rendered without the source, which is not kept
+func (vm *VM) Run(ctx context.Context, script *compiler.Function) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	runner, ok := vm.engine.(interface {
+		Run(script *compiler.Function) error
+	})
+	if !ok {
+		return ErrNotBytecode
+	}
+	vm.diag.Reset()
+	vm.engine.SetContext(ctx)
+
+	if err := runner.Run(script); err != nil {
+		return vm.error("", err)
+	}
+	return nil
+}
+
+// Call Calls the global function or class with the arguments converted to Lox values.
+// The result is converted back as described in FromValue
+func (vm *VM) Call(name string, args ...interface{}) (interface{}, error) {
+	return vm.CallContext(context.Background(), name, args...)
+}
+
+// CallContext Is Call which stops with a runtime error once ctx is done
+func (vm *VM) CallContext(ctx context.Context, name string, args ...interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vm.diag.Reset()
+	vm.engine.SetContext(ctx)
+	callee, ok := vm.engine.GetGlobal(name)
+	if !ok {
+		return nil, &UndefinedError{Name: name}
+	}
+
+	arguments, err := toValues(args)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := vm.engine.Call(callee, arguments)
+	if err != nil {
+		if vm.diag.HasErrors() {
+			return nil, vm.error("", err)
+		}
+		return nil, err
+	}
+	return FromValue(result), nil
+}
+
+// SetGlobal Defines the global variable with the Go value converted as described in ToValue
+func (vm *VM) SetGlobal(name string, v interface{}) error {
+	// Go functions are named after the global in stack traces
+	switch fn := v.(type) {
+	case Func:
+		v = toNative(name, fn)
+	case func(args ...interface{}) (interface{}, error):
+		v = toNative(name, fn)
+	}
+
+	val, err := ToValue(v)
+	if err != nil {
+		return err
+	}
+	vm.engine.SetGlobal(name, val)
+	return nil
+}
+
+// GetGlobal Returns the global variable converted as described in FromValue
+func (vm *VM) GetGlobal(name string) (interface{}, error) {
+	val, ok := vm.engine.GetGlobal(name)
+	if !ok {
+		return nil, &UndefinedError{Name: name}
+	}
+	return FromValue(val), nil
+}
+
+// UndefinedError Is returned for globals which are not defined
+type UndefinedError struct {
+	Name string
+}
+
+func (e *UndefinedError) Error() string {
+	return "Undefined variable '" + e.Name + "'."
+}
+
+func (vm *VM) error(source string, err error) error {
+	rendered := strings.Builder{}
+	vm.diag.Render(&rendered, source)
+	return &Error{
+		Diagnostics: vm.diag.Diagnostics(),
+		rendered:    rendered.String(),
+		err:         err,
+	}
+}
@@ -0,0 +1,422 @@
+package glox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+func TestEvalWritesToStdout(t *testing.T) {
+	out := &bytes.Buffer{}
+	vm := New(Options{Stdout: out})
+
+	if err := vm.Eval(context.Background(), `print "hello"; writeLine(1, 2);`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "hello\n1 2\n" {
+		t.Errorf("output = %q, want %q", got, "hello\n1 2\n")
+	}
+}
+
+func TestEvalKeepsGlobals(t *testing.T) {
+	vm := New(Options{Stdout: &bytes.Buffer{}})
+	ctx := context.Background()
+
+	if err := vm.Eval(ctx, `var count = 1; fun add(a, b) { return a + b; }`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := vm.Eval(ctx, `count = add(count, 2);`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := vm.GetGlobal("count")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 3.0 {
+		t.Errorf("count = %v, want 3", got)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		message string
+		runtime bool
+	}{
+		{"parse error", `var = 1;`, "Expect", false},
+		{"resolve error", `return 1;`, "Can't return from top-level code.", false},
+		{"runtime error", `1 + nil;`, "Operands must be two numbers or two strings.", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := New(Options{Stdout: &bytes.Buffer{}})
+			err := vm.Eval(context.Background(), tt.source)
+
+			var gloxError *Error
+			if !errors.As(err, &gloxError) {
+				t.Fatalf("error is %T, want *Error", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.message)
+			}
+
+			var runtimeError *utils.RuntimeError
+			if got := errors.As(err, &runtimeError); got != tt.runtime {
+				t.Errorf("is runtime error = %v, want %v", got, tt.runtime)
+			}
+		})
+	}
+}
+
+func TestEvalCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := New(Options{}).Eval(ctx, `print 1;`)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	vm := New(Options{Stdout: &bytes.Buffer{}})
+	err := vm.Eval(context.Background(), `
+fun describe(name, tags) { return {"name": name, "tags": tags, "count": len(tags)}; }
+fun fail() { return nil + 1; }
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := vm.Call("describe", "lox", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]interface{}{
+		"name":  "lox",
+		"tags":  []interface{}{"a", "b"},
+		"count": 2.0,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("describe = %#v, want %#v", got, want)
+	}
+
+	if _, err := vm.Call("describe", "too few"); err == nil {
+		t.Errorf("expected an arity error")
+	}
+	if _, err := vm.Call("missing"); err == nil {
+		t.Errorf("expected an undefined error")
+	}
+
+	_, err = vm.Call("fail")
+	var runtimeError *utils.RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Errorf("error is %T, want a runtime error", err)
+	}
+}
+
+func TestCallReturnsCycle(t *testing.T) {
+	vm := New(Options{Stdout: &bytes.Buffer{}})
+	err := vm.Eval(context.Background(), `
+var xs = [1]; push(xs, xs);
+var m = {"shared": [2]}; m["again"] = m["shared"]; m["self"] = m;
+fun list() { return xs; }
+fun map() { return m; }
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := vm.Call("list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	elements, ok := got.([]interface{})
+	if !ok || len(elements) != 2 || elements[0] != 1.0 {
+		t.Fatalf("list = %#v, want [1 xs]", got)
+	}
+	if _, ok := elements[1].(*value.List); !ok {
+		t.Errorf("repeated list is %T, want *value.List", elements[1])
+	}
+
+	got, err = vm.Call("map")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, ok := got.(map[string]interface{})
+	if !ok {
+		t.Fatalf("map = %#v, want a Go map", got)
+	}
+	if _, ok := entries["self"].(*value.Map); !ok {
+		t.Errorf("repeated map is %T, want *value.Map", entries["self"])
+	}
+	// A list in two entries is not a cycle, both are converted
+	if !reflect.DeepEqual(entries["shared"], []interface{}{2.0}) || !reflect.DeepEqual(entries["again"], []interface{}{2.0}) {
+		t.Errorf("shared = %#v, again = %#v, want [2] for both", entries["shared"], entries["again"])
+	}
+}
+
+func TestToValueCycle(t *testing.T) {
+	list := []interface{}{1, nil}
+	list[1] = list
+	if _, err := ToValue(list); err == nil {
+		t.Errorf("expected an error for a slice which contains itself")
+	}
+
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, err := ToValue(m); err == nil {
+		t.Errorf("expected an error for a map which contains itself")
+	}
+
+	// A slice in two places is not a cycle
+	shared := []interface{}{2}
+	got, err := ToValue([]interface{}{shared, shared})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value.ToString(got) != "[[2], [2]]" {
+		t.Errorf("ToValue = %s, want [[2], [2]]", value.ToString(got))
+	}
+}
+
+func TestSetGlobal(t *testing.T) {
+	out := &bytes.Buffer{}
+	vm := New(Options{Stdout: out})
+
+	if err := vm.SetGlobal("config", map[string]interface{}{"retries": 3, "hosts": []string{"a"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := vm.SetGlobal("double", func(args ...interface{}) (interface{}, error) {
+		return args[0].(float64) * 2, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := vm.SetGlobal("bad", struct{}{}); err == nil {
+		t.Errorf("expected a conversion error")
+	}
+
+	if err := vm.Eval(context.Background(), `print double(config["retries"]); print config["hosts"];`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "6\n[a]\n" {
+		t.Errorf("output = %q, want %q", got, "6\n[a]\n")
+	}
+}
+
+func TestHostFunctionError(t *testing.T) {
+	vm := New(Options{Stdout: &bytes.Buffer{}})
+	vm.SetGlobal("fail", func(args ...interface{}) (interface{}, error) {
+		return nil, errors.New("host failure")
+	})
+
+	err := vm.Eval(context.Background(), `try { fail(); } catch (e) { print e.message; } fail();`)
+	if err == nil || !strings.Contains(err.Error(), "host failure") {
+		t.Errorf("error = %v, want the host failure", err)
+	}
+}
+
+func TestEvalDeadline(t *testing.T) {
+	vm := New(Options{Stdout: &bytes.Buffer{}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := vm.Eval(ctx, `while (true) {}`)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The VM can be used again with a fresh context
+	if err := vm.Eval(context.Background(), `var x = 1;`); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEvalDeadlineWhileSleeping(t *testing.T) {
+	for _, engine := range []Engine{TREE_WALKER, BYTECODE} {
+		out := &bytes.Buffer{}
+		vm := New(Options{Stdout: out, Engine: engine})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		start := time.Now()
+		err := vm.Eval(ctx, `try { sleep(3); } catch (e) {} print "woke";`)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("engine %d: error = %v, want %v", engine, err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("engine %d: Eval returned after %v, want it to stop at the deadline", engine, elapsed)
+		}
+		if out.Len() != 0 {
+			t.Errorf("engine %d: output = %q, want nothing", engine, out.String())
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	vm := New(Options{
+		Stdout: &bytes.Buffer{},
+		Limits: Limits{MaxSteps: 100},
+	})
+	ctx := context.Background()
+
+	err := vm.Eval(ctx, `fun spin() { while (true) {} }`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := vm.Call("spin"); !errors.Is(err, ErrStepLimit) {
+		t.Errorf("error = %v, want %v", err, ErrStepLimit)
+	}
+
+	// Each call gets the whole budget
+	err = vm.Eval(ctx, `fun recurse(n) { return recurse(n + 1); }`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := vm.Call("recurse", 0); !errors.Is(err, ErrStepLimit) {
+		t.Errorf("error = %v, want %v", err, ErrStepLimit)
+	}
+}
+
+func TestDeepRecursionIsRuntimeError(t *testing.T) {
+	vm := New(Options{Stdout: &bytes.Buffer{}})
+
+	err := vm.Eval(context.Background(), `fun f(n) { return f(n + 1); } f(0);`)
+	if !errors.Is(err, ErrStackLimit) {
+		t.Fatalf("error = %v, want %v", err, ErrStackLimit)
+	}
+	var runtimeError *utils.RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Errorf("error is %T, want a runtime error", err)
+	}
+}
+
+func TestBytecodeEngine(t *testing.T) {
+	out := &bytes.Buffer{}
+	vm := New(Options{Stdout: out, Engine: BYTECODE})
+	ctx := context.Background()
+
+	err := vm.SetGlobal("double", func(args ...interface{}) (interface{}, error) {
+		return args[0].(float64) * 2, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = vm.Eval(ctx, `
+var count = double(2);
+class Point { init(x) { this.x = x; } }
+fun describe(p) { return "x=" + str(p.x); }
+fun fail() { return nil + 1; }
+print count;
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := vm.Eval(ctx, `count = count + 1;`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "4\n" {
+		t.Errorf("output = %q, want %q", got, "4\n")
+	}
+
+	count, err := vm.GetGlobal("count")
+	if err != nil || count != 5.0 {
+		t.Errorf("count = %v, %v, want 5", count, err)
+	}
+
+	point, err := vm.Call("Point", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := vm.Call("describe", point)
+	if err != nil || got != "x=3" {
+		t.Errorf("describe = %v, %v, want x=3", got, err)
+	}
+	if _, err := vm.Call("describe"); err == nil {
+		t.Errorf("expected an arity error")
+	}
+
+	_, err = vm.Call("fail")
+	var runtimeError *utils.RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Errorf("error is %T, want a runtime error", err)
+	}
+}
+
+func TestCompileAndRun(t *testing.T) {
+	script, err := New(Options{}).Compile(`var greeting = "hi"; print greeting + "!";`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := &bytes.Buffer{}
+	vm := New(Options{Stdout: out, Engine: BYTECODE})
+	if err := vm.Run(context.Background(), script); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "hi!\n" {
+		t.Errorf("output = %q, want %q", got, "hi!\n")
+	}
+	if got, _ := vm.GetGlobal("greeting"); got != "hi" {
+		t.Errorf("greeting = %v, want hi", got)
+	}
+
+	if err := New(Options{}).Run(context.Background(), script); !errors.Is(err, ErrNotBytecode) {
+		t.Errorf("error = %v, want %v", err, ErrNotBytecode)
+	}
+	if _, err := New(Options{}).Compile(`var = 1;`); err == nil {
+		t.Errorf("expected a parse error")
+	}
+}
+
+func TestRunDamaged(t *testing.T) {
+	script, err := New(Options{}).Compile(`
+		fun counter() { var n = 0; fun next() { n = n + 1; return n; } return next; }
+		var next = counter();
+		var items = [next(), next()];
+		var table = {"a": 1};
+		for (item in items) { table[str(item)] = item; }
+		try { throw "boom"; } catch (e) { print e; }
+		class A { init(x) { this.x = x; } get() { return this.x; } }
+		print A(len(items)).get() + table["a"];`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	encoded := &bytes.Buffer{}
+	if err := compiler.Encode(encoded, script); err != nil {
+		t.Fatal(err)
+	}
+	valid := encoded.Bytes()
+
+	// Every mutation Decode accepts has to run to the end or stop with an error
+	ran := 0
+	for k := range valid {
+		for _, flip := range []byte{0x01, 0x80, 0xff} {
+			mutated := append([]byte{}, valid...)
+			mutated[k] ^= flip
+			damaged, err := compiler.Decode(bytes.NewReader(mutated))
+			if err != nil {
+				continue
+			}
+
+			vm := New(Options{Stdout: io.Discard, Engine: BYTECODE, Limits: Limits{MaxSteps: 10000}})
+			// A runtime error is fine, a panic fails the test
+			vm.Run(context.Background(), damaged)
+			ran++
+		}
+	}
+	t.Logf("ran %d of %d mutations", ran, 3*len(valid))
+}
@@ -2,59 +2,92 @@ package interpreter
 
 import (
 	"errors"
-	"time"
 
 	"github.com/madraceee/interpreters/glox/environment"
 	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
 )
 
 type LoxCallable interface {
+	value.Value
 	Arity() int
-	Call(i *Interpreter, arguments []interface{}) (interface{}, error)
-}
-
-// In-built clock function
-type clock struct{}
-
-func (c *clock) Arity() int {
-	return 0
-}
-
-func (c *clock) Call(i *Interpreter, _obj []interface{}) (interface{}, error) {
-	return time.Now(), nil
+	Call(i *Interpreter, arguments []value.Value) (value.Value, error)
 }
 
 // User-defined Function
 type LoxFunction struct {
 	Declaration *parser.Function
 	Closure     *environment.Environment
+	// Module the function is declared in, whose globals it uses
+	Module        *Module
+	IsInitializer bool
 }
 
-func NewLoxFunction(declaration *parser.Function, closure *environment.Environment) *LoxFunction {
+func NewLoxFunction(declaration *parser.Function, closure *environment.Environment, module *Module, isInitializer bool) *LoxFunction {
 	return &LoxFunction{
-		Declaration: declaration,
-		Closure:     closure,
+		Declaration:   declaration,
+		Closure:       closure,
+		Module:        module,
+		IsInitializer: isInitializer,
 	}
 }
 
-func (lf *LoxFunction) Call(i *Interpreter, arguments []interface{}) (interface{}, error) {
+// Bind Returns a copy of the method whose closure has "this" set to the instance
+func (lf *LoxFunction) Bind(instance *LoxInstance) *LoxFunction {
+	env := environment.NewEnvironment(lf.Closure)
+	env.Define("this", instance)
+	return NewLoxFunction(lf.Declaration, env, lf.Module, lf.IsInitializer)
+}
+
+func (lf *LoxFunction) Call(i *Interpreter, arguments []value.Value) (value.Value, error) {
 	environment := environment.NewEnvironment(lf.Closure)
 	for i, dec := range lf.Declaration.Params {
 		environment.Define(dec.Lexeme, arguments[i])
 	}
 
-	err := i.executeBlock(lf.Declaration.Body, environment)
+	defer i.enterModule(lf.Module)()
+	_, err := i.executeBlock(lf.Declaration.Body, environment)
 	returnError := &ReturnError{}
 	if errors.As(err, &returnError) {
-		return err.(*ReturnError).Value, nil
+		if lf.IsInitializer {
+			return lf.this()
+		}
+		return returnError.Value, nil
 	}
-	return nil, err
+	if err != nil {
+		// Trace is taken before leaving the module so it has the right file
+		return nil, i.withStackTrace(err)
+	}
+
+	// Initializers always return the instance
+	if lf.IsInitializer {
+		return lf.this()
+	}
+	return value.Nil, nil
+}
+
+func (lf *LoxFunction) this() (value.Value, error) {
+	return lf.Closure.GetAt(0, 0, token.NewToken(token.THIS, "this", nil, lf.Declaration.Name.Line, lf.Declaration.Name.Column))
 }
 
 func (lf *LoxFunction) Arity() int {
 	return len(lf.Declaration.Params)
 }
 
+func (lf *LoxFunction) Type() value.Type {
+	return value.CALLABLE_TYPE
+}
+
 func (lf *LoxFunction) String() string {
-	return "<fn " + lf.Declaration.Name.Lexeme + " >"
+	return "<fn " + lf.Name() + ">"
+}
+
+// Name Returns the name of the function, or "anonymous" for a lambda
+// whose Declaration is named by its 'fun' or '(' token
+func (lf *LoxFunction) Name() string {
+	if lf.Declaration.Name.TokenType != token.IDENTIFIER {
+		return "anonymous"
+	}
+	return lf.Declaration.Name.Lexeme
 }
@@ -0,0 +1,103 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+type LoxClass struct {
+	Name       string
+	Superclass *LoxClass
+	Methods    map[string]*LoxFunction
+}
+
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
+	return &LoxClass{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+}
+
+// FindMethod Looks for the method in the class and then up the superclass chain
+func (lc *LoxClass) FindMethod(name string) (*LoxFunction, bool) {
+	if method, ok := lc.Methods[name]; ok {
+		return method, true
+	}
+
+	if lc.Superclass != nil {
+		return lc.Superclass.FindMethod(name)
+	}
+	return nil, false
+}
+
+// Call Creates a new instance and runs the initializer if present
+func (lc *LoxClass) Call(i *Interpreter, arguments []value.Value) (value.Value, error) {
+	instance := NewLoxInstance(lc)
+	if initializer, ok := lc.FindMethod("init"); ok {
+		_, err := initializer.Bind(instance).Call(i, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (lc *LoxClass) Arity() int {
+	if initializer, ok := lc.FindMethod("init"); ok {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (lc *LoxClass) Type() value.Type {
+	return value.CLASS_TYPE
+}
+
+func (lc *LoxClass) String() string {
+	return lc.Name
+}
+
+type LoxInstance struct {
+	Class  *LoxClass
+	fields map[string]value.Value
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{
+		Class:  class,
+		fields: make(map[string]value.Value),
+	}
+}
+
+// Get Returns the field if present, else the method bound to the instance
+func (li *LoxInstance) Get(name token.Token) (value.Value, error) {
+	if val, ok := li.fields[name.Lexeme]; ok {
+		return val, nil
+	}
+
+	if method, ok := li.Class.FindMethod(name.Lexeme); ok {
+		return method.Bind(li), nil
+	}
+
+	return nil, &utils.RuntimeError{
+		Token: name,
+		Err:   errors.New("Undefined property '" + name.Lexeme + "'."),
+	}
+}
+
+func (li *LoxInstance) Set(name token.Token, val value.Value) {
+	li.fields[name.Lexeme] = val
+}
+
+func (li *LoxInstance) Type() value.Type {
+	return value.INSTANCE_TYPE
+}
+
+func (li *LoxInstance) String() string {
+	return li.Class.Name + " instance"
+}
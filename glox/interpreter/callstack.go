@@ -0,0 +1,70 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+)
+
+// callFrame A call which is currently executing
+type callFrame struct {
+	function string
+	// Token of the call expression which created the frame
+	// and the file the call expression is in
+	callSite token.Token
+	file     string
+}
+
+// callableName Returns the name shown for the callable in stack traces
+func callableName(callable LoxCallable) string {
+	switch c := callable.(type) {
+	case *LoxFunction:
+		return c.Name()
+	case *LoxClass:
+		return c.Name
+	case *nativeFunction:
+		return c.native.Name
+	}
+	return callable.String()
+}
+
+// withStackTrace Attaches the current call stack to a runtime error
+// which does not have one yet. The error is assumed to be raised
+// inside the innermost frame
+func (i *Interpreter) withStackTrace(err error) error {
+	runtimeError := &utils.RuntimeError{}
+	if !errors.As(err, &runtimeError) || runtimeError.Trace != nil {
+		return err
+	}
+
+	runtimeError.Trace = i.stackTrace(runtimeError.Token)
+	return err
+}
+
+// stackTrace Returns the frames of the call stack, innermost first.
+// at is the position where the innermost frame currently is
+func (i *Interpreter) stackTrace(at token.Token) []utils.StackFrame {
+	frames := make([]callFrame, 0, i.callStack.Length())
+	next, has := i.callStack.Itr()
+	for has() {
+		frames = append(frames, next())
+	}
+
+	trace := make([]utils.StackFrame, 0, len(frames)+1)
+	file := i.module.File
+	for k := len(frames) - 1; k >= 0; k-- {
+		trace = append(trace, stackFrame(frames[k].function, file, at))
+		at, file = frames[k].callSite, frames[k].file
+	}
+	return append(trace, stackFrame("script", file, at))
+}
+
+func stackFrame(function, file string, at token.Token) utils.StackFrame {
+	return utils.StackFrame{
+		Function: function,
+		File:     file,
+		Line:     at.Line,
+		Column:   at.Column,
+	}
+}
@@ -0,0 +1,134 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+func (i *Interpreter) VisitListExpr(l *parser.List) (interface{}, error) {
+	elements := make([]value.Value, 0, len(l.Elements))
+	for _, element := range l.Elements {
+		val, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, val)
+	}
+
+	return value.NewList(elements), nil
+}
+
+func (i *Interpreter) VisitMapExpr(m *parser.Map) (interface{}, error) {
+	entries := value.NewMap()
+	for k := range m.Keys {
+		key, err := i.evaluate(m.Keys[k])
+		if err != nil {
+			return nil, err
+		}
+		val, err := i.evaluate(m.Values[k])
+		if err != nil {
+			return nil, err
+		}
+		entries.Set(key, val)
+	}
+
+	return entries, nil
+}
+
+func (i *Interpreter) VisitIndexExpr(ie *parser.Index) (interface{}, error) {
+	object, err := i.evaluate(ie.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(ie.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch collection := object.(type) {
+	case *value.List:
+		position, err := i.checkListIndex(ie.Bracket, collection, index)
+		if err != nil {
+			return nil, err
+		}
+		return collection.Elements[position], nil
+	case *value.Map:
+		val, ok := collection.Get(index)
+		if !ok {
+			return nil, &utils.RuntimeError{
+				Token: ie.Bracket,
+				Err:   errors.New("Undefined key '" + value.ToString(index) + "'."),
+			}
+		}
+		return val, nil
+	}
+
+	return nil, notIndexable(ie.Bracket)
+}
+
+func (i *Interpreter) VisitSetIndexExpr(s *parser.SetIndex) (interface{}, error) {
+	object, err := i.evaluate(s.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(s.Index)
+	if err != nil {
+		return nil, err
+	}
+	val, err := i.evaluate(s.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch collection := object.(type) {
+	case *value.List:
+		position, err := i.checkListIndex(s.Bracket, collection, index)
+		if err != nil {
+			return nil, err
+		}
+		collection.Elements[position] = val
+		return val, nil
+	case *value.Map:
+		collection.Set(index, val)
+		return val, nil
+	}
+
+	return nil, notIndexable(s.Bracket)
+}
+
+func notIndexable(bracket token.Token) error {
+	return &utils.RuntimeError{
+		Token: bracket,
+		Err:   errors.New("Only lists and maps can be indexed."),
+	}
+}
+
+// checkListIndex Returns the position of the element in the list
+// or a RuntimeError if the index is out of bounds
+func (i *Interpreter) checkListIndex(bracket token.Token, list *value.List, index value.Value) (int, error) {
+	position, err := toIndex(index, len(list.Elements))
+	if err != nil {
+		return 0, &utils.RuntimeError{
+			Token: bracket,
+			Err:   err,
+		}
+	}
+	return position, nil
+}
+
+// toIndex Converts the value into a position in [0, length)
+func toIndex(index value.Value, length int) (int, error) {
+	number, ok := value.AsNumber(index)
+	if !ok || number != math.Trunc(number) {
+		return 0, errors.New("Index must be an integer.")
+	}
+	if number < 0 || number >= float64(length) {
+		return 0, errors.New("Index out of bounds.")
+	}
+	return int(number), nil
+}
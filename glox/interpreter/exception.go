@@ -0,0 +1,136 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/environment"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// LoxError An error object with the message, line and stack of an error.
+// Runtime errors are caught as a LoxError and scripts create them with Error(message)
+type LoxError struct {
+	Message string
+	Line    int
+	Stack   []utils.StackFrame
+}
+
+func (le *LoxError) Get(name token.Token) (value.Value, error) {
+	switch name.Lexeme {
+	case "message":
+		return value.String(le.Message), nil
+	case "line":
+		return value.Number(le.Line), nil
+	case "stack":
+		frames := make([]value.Value, 0, len(le.Stack))
+		for _, frame := range le.Stack {
+			frames = append(frames, value.String(frame.String()))
+		}
+		return value.NewList(frames), nil
+	}
+
+	return nil, &utils.RuntimeError{
+		Token: name,
+		Err:   errors.New("Undefined property '" + name.Lexeme + "'."),
+	}
+}
+
+func (le *LoxError) Type() value.Type {
+	return value.ERROR_TYPE
+}
+
+func (le *LoxError) String() string {
+	return "Error: " + le.Message
+}
+
+// ErrorNative Creates an error object with the message
+var ErrorNative = &stdlib.NativeFunction{
+	Name:  "Error",
+	Arity: 1,
+	Fn: func(arguments []value.Value) (value.Value, error) {
+		return &LoxError{Message: value.ToString(arguments[0])}, nil
+	},
+}
+
+// Thrown The error of the RuntimeError raised by a throw statement
+type Thrown struct {
+	Value value.Value
+}
+
+func (t *Thrown) Error() string {
+	return "Uncaught " + value.ToString(t.Value)
+}
+
+func (i *Interpreter) VisitThrowStmt(t *parser.Throw) (interface{}, error) {
+	val, err := i.evaluate(t.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, &utils.RuntimeError{
+		Token: t.Keyword,
+		Err:   &Thrown{Value: val},
+	}
+}
+
+// VisitTryStmt Runs the catch body if the try body raised an error
+// and the finally body in every case. A finally body which throws, returns,
+// breaks or continues replaces the outcome of the try and catch bodies
+func (i *Interpreter) VisitTryStmt(t *parser.Try) (interface{}, error) {
+	result, err := i.executeBlock(t.Body, environment.NewEnvironment(i.Environment))
+
+	if err != nil && t.CatchBody != nil {
+		if caught, ok := i.caught(err); ok {
+			env := environment.NewEnvironment(i.Environment)
+			env.Define(t.CatchName.Lexeme, caught)
+			result, err = i.executeBlock(t.CatchBody, env)
+		}
+	}
+
+	if t.FinallyBody != nil {
+		finallyResult, finallyErr := i.executeBlock(t.FinallyBody, environment.NewEnvironment(i.Environment))
+		if finallyErr != nil {
+			return nil, finallyErr
+		}
+		if finallyResult != nil {
+			return finallyResult, nil
+		}
+	}
+
+	return result, err
+}
+
+// caught Returns the value a catch clause binds for the error.
+// Returns false for errors which are not exceptions, such as a return
+func (i *Interpreter) caught(err error) (value.Value, bool) {
+	runtimeError := &utils.RuntimeError{}
+	if !errors.As(err, &runtimeError) || IsFatal(err) {
+		return nil, false
+	}
+	i.withStackTrace(runtimeError)
+	return Caught(runtimeError), true
+}
+
+// Caught Returns the value a catch clause binds for the runtime error.
+// Thrown values are bound as they are and other errors as a LoxError
+func Caught(runtimeError *utils.RuntimeError) value.Value {
+	t := &Thrown{}
+	if !errors.As(runtimeError.Err, &t) {
+		return &LoxError{
+			Message: runtimeError.Err.Error(),
+			Line:    runtimeError.Token.Line,
+			Stack:   runtimeError.Trace,
+		}
+	}
+
+	// Error objects get the position they were first thrown from
+	if loxError, ok := t.Value.(*LoxError); ok && loxError.Stack == nil {
+		loxError.Line = runtimeError.Token.Line
+		loxError.Stack = runtimeError.Trace
+	}
+	return t.Value
+}
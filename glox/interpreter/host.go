@@ -0,0 +1,62 @@
+package interpreter
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Native Returns the native function as a value which Lox can call
+func Native(fn *stdlib.NativeFunction) value.Value {
+	return &nativeFunction{native: fn}
+}
+
+// GetGlobal Returns the global variable of the script or the native with the name
+func (i *Interpreter) GetGlobal(name string) (value.Value, bool) {
+	val, err := i.script.Globals.Get(token.NewToken(token.IDENTIFIER, name, nil, 0, 0))
+	return val, err == nil
+}
+
+// SetGlobal Defines or replaces the global variable of the script
+func (i *Interpreter) SetGlobal(name string, val value.Value) {
+	if fn, ok := val.(*stdlib.NativeFunction); ok {
+		val = Native(fn)
+	}
+	i.script.Globals.Define(name, val)
+}
+
+// Call Calls a function or class from the host program.
+// Runtime errors are reported to the collector and returned
+func (i *Interpreter) Call(callee value.Value, arguments []value.Value) (value.Value, error) {
+	if native, ok := callee.(*stdlib.NativeFunction); ok {
+		callee = Native(native)
+	}
+	function, ok := callee.(LoxCallable)
+	if !ok {
+		return nil, errors.New("Can only call functions and classes.")
+	}
+	if function.Arity() != stdlib.VARIADIC && len(arguments) != function.Arity() {
+		return nil, errors.New("Expected " + strconv.Itoa(function.Arity()) + " arguments but got " + strconv.Itoa(len(arguments)) + ".")
+	}
+
+	// Host calls have no call site in the source
+	err := i.enterCall(token.Token{}, callFrame{
+		function: callableName(function),
+		file:     i.module.File,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer i.callStack.Pop()
+
+	result, err := function.Call(i, arguments)
+	if err != nil {
+		err = i.withStackTrace(err)
+		i.reportRuntimeError(err)
+		return nil, err
+	}
+	return result, nil
+}
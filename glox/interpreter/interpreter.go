@@ -1,71 +1,228 @@
 package interpreter
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 
+	"github.com/madraceee/interpreters/glox/diagnostics"
 	"github.com/madraceee/interpreters/glox/environment"
 	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/stdlib"
 	"github.com/madraceee/interpreters/glox/token"
 	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
 )
 
+// local Where a local variable reference finds its variable
+type local struct {
+	depth int
+	slot  int
+}
+
 type Interpreter struct {
+	// Globals of the module which is currently executing
 	globals     *environment.Environment
 	Environment *environment.Environment
+	// Native functions, enclosing the globals of every module
+	builtins *environment.Environment
+	// Scope depth and slot of every local variable reference, filled by the resolver
+	locals map[parser.Expr]local
+	// Lox functions which are currently executing, used for stack traces
+	callStack utils.Stack[callFrame]
+	// script is the file being run and module the one currently executing
+	script *Module
+	module *Module
+	// Executed modules by absolute path, so each one runs once
+	modules map[string]*Module
+	// Absolute paths of the modules being imported, outermost first
+	importing []string
+	// Directories searched for modules not found next to the importing file
+	searchPath []string
+	// Where print writes to
+	out io.Writer
+	// Context which stops the script and the channel it is done on
+	ctx    context.Context
+	done   <-chan struct{}
+	limits Limits
+	// Statements executed since the context was set
+	steps int
+	diag  *diagnostics.Collector
 }
 
-func NewInterpreter() *Interpreter {
-	env := environment.NewEnvironment(nil)
-
-	env.Define("clock", clock{})
-	return &Interpreter{
-		globals:     env,
-		Environment: env,
-	}
+func NewInterpreter(diag *diagnostics.Collector) *Interpreter {
+	builtins := environment.NewGlobals(nil)
+	script := newModule("script", "script", builtins)
+	i := &Interpreter{
+		globals:     script.Globals,
+		Environment: script.Globals,
+		builtins:    builtins,
+		locals:      make(map[parser.Expr]local),
+		callStack:   utils.NewStack[callFrame](),
+		script:      script,
+		module:      script,
+		modules:     make(map[string]*Module),
+		diag:        diag,
+	}
+
+	stdlib.Install(i, stdlib.Collections, stdlib.Math, stdlib.String, stdlib.Time)
+	i.SetIO(os.Stdin, os.Stdout)
+	i.DefineNative(ErrorNative)
+	i.SetContext(context.Background())
+	i.SetLimits(Limits{})
+	return i
 }
 
-type RuntimeError struct {
-	Token token.Token
-	Err   error
+// SetIO Sets where print and the io functions write to and read from
+func (i *Interpreter) SetIO(in io.Reader, out io.Writer) {
+	i.out = out
+	stdlib.Install(i, stdlib.IO(in, out))
 }
 
-func (e *RuntimeError) Error() string {
-	utils.HadRunTimeError = true
-	lineStr := strconv.Itoa(e.Token.Line)
-	return "Runtime Error: " + e.Err.Error() + "\n[Line " + lineStr + "]\n"
+// SetFileName Sets the file name shown in stack traces.
+// Imports in the script are resolved relative to it
+func (i *Interpreter) SetFileName(fileName string) {
+	i.script.File = fileName
+
+	// Script counts as being imported so importing it back is a cycle
+	if path, err := filepath.Abs(fileName); err == nil {
+		i.importing = []string{path}
+	}
 }
 
-func (i *Interpreter) Interpret(stmts []parser.Stmt) {
+// Interpret Executes the statements till the first runtime error,
+// which is reported to the collector and returned
+func (i *Interpreter) Interpret(stmts []parser.Stmt) error {
 	for _, stmt := range stmts {
 		_, err := i.execute(stmt)
 		if err != nil {
-			fmt.Println(err.Error())
-			return
+			err = i.withStackTrace(err)
+			i.reportRuntimeError(err)
+			return err
 		}
 	}
+	return nil
+}
+
+func (i *Interpreter) reportRuntimeError(err error) {
+	runtimeError := &utils.RuntimeError{}
+	if !errors.As(err, &runtimeError) {
+		i.diag.Report(diagnostics.Diagnostic{
+			Severity: diagnostics.ERROR,
+			Code:     diagnostics.RUNTIME_ERROR,
+			Message:  err.Error(),
+		})
+		return
+	}
+
+	// Errors raised inside an imported module point into its file
+	file := ""
+	if len(runtimeError.Trace) > 0 && runtimeError.Trace[0].File != i.script.File {
+		file = runtimeError.Trace[0].File
+	}
+	i.diag.Report(diagnostics.Diagnostic{
+		Severity: diagnostics.ERROR,
+		Code:     diagnostics.RUNTIME_ERROR,
+		Message:  runtimeError.Err.Error(),
+		Span:     runtimeError.Token.Span(),
+		Notes:    runtimeError.Notes(),
+		File:     file,
+	})
 }
 
+// execute Executes the statement as one step of the budget.
+// Every loop iteration and call runs statements, so this is
+// where runaway scripts are stopped
 func (i *Interpreter) execute(stmt parser.Stmt) (interface{}, error) {
+	if err := i.step(); err != nil {
+		return nil, &utils.RuntimeError{Token: token.FromSpan(stmt.Span()), Err: err}
+	}
 	return stmt.Visit(i)
 }
 
+// Resolve Stores the number of scopes between the expression and the
+// scope where the variable it refers to is declared, along with its slot there
+func (i *Interpreter) Resolve(expr parser.Expr, depth, slot int) {
+	i.locals[expr] = local{depth: depth, slot: slot}
+}
+
+// lookUpVariable Uses the resolved depth and slot to fetch the variable.
+// Variables which are not resolved are treated as globals
+func (i *Interpreter) lookUpVariable(name token.Token, expr parser.Expr) (value.Value, error) {
+	if l, ok := i.locals[expr]; ok {
+		return i.Environment.GetAt(l.depth, l.slot, name)
+	}
+
+	return i.globals.Get(name)
+}
+
 func (i *Interpreter) VisitBlockStmt(b *parser.Block) (interface{}, error) {
-	return nil, i.executeBlock(b.Statements, environment.NewEnvironment(i.Environment))
+	return i.executeBlock(b.Statements, environment.NewEnvironment(i.Environment))
+}
+
+func (i *Interpreter) VisitBreakStmt(b *parser.Break) (interface{}, error) {
+	return BREAK_SIGNAL, nil
+}
+
+func (i *Interpreter) VisitContinueStmt(c *parser.Continue) (interface{}, error) {
+	return CONTINUE_SIGNAL, nil
 }
 
 func (i *Interpreter) VisitVarStmt(v *parser.Var) (interface{}, error) {
-	var value interface{}
+	// Variables without an initializer hold nil
+	var val value.Value = value.Nil
 	var err error
 	if v.Initializer != nil {
-		value, err = i.evaluate(v.Initializer)
+		val, err = i.evaluate(v.Initializer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	i.Environment.Define(v.Name.Lexeme, val)
+	return nil, nil
+}
+
+func (i *Interpreter) VisitClassStmt(c *parser.Class) (interface{}, error) {
+	var superclass *LoxClass
+	if c.Superclass != nil {
+		val, err := i.evaluate(c.Superclass)
 		if err != nil {
 			return nil, err
 		}
+
+		class, ok := val.(*LoxClass)
+		if !ok {
+			return nil, &utils.RuntimeError{
+				Token: c.Superclass.Name,
+				Err:   errors.New("Superclass must be a class."),
+			}
+		}
+		superclass = class
+	}
+
+	// Methods of a subclass close over an environment holding "super"
+	if superclass != nil {
+		i.Environment = environment.NewEnvironment(i.Environment)
+		i.Environment.Define("super", superclass)
+	}
+
+	methods := make(map[string]*LoxFunction)
+	for _, method := range c.Methods {
+		methods[method.Name.Lexeme] = NewLoxFunction(method, i.Environment, i.module, method.Name.Lexeme == "init")
+	}
+
+	class := NewLoxClass(c.Name.Lexeme, superclass, methods)
+	if superclass != nil {
+		i.Environment = i.Environment.Enclosing
 	}
 
-	i.Environment.Define(v.Name.Lexeme, value)
+	// Methods only look the class up once they are called, so it can be defined last
+	i.Environment.Define(c.Name.Lexeme, class)
 	return nil, nil
 }
 
@@ -75,7 +232,7 @@ func (i *Interpreter) VisitExpressionStmt(e *parser.Expression) (interface{}, er
 }
 
 func (i *Interpreter) VisitFunctionStmt(f *parser.Function) (interface{}, error) {
-	function := NewLoxFunction(f, i.Environment)
+	function := NewLoxFunction(f, i.Environment, i.module, false)
 	i.Environment.Define(f.Name.Lexeme, function)
 	return nil, nil
 }
@@ -85,19 +242,15 @@ func (i *Interpreter) VisitPrintStmt(p *parser.Print) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	if val == nil {
-		fmt.Println("nil")
-		return nil, nil
-	}
-	fmt.Println(token.GetStringValue(val.(token.Object)))
+	fmt.Fprintln(i.out, value.ToString(val))
 	return nil, nil
 }
 
 func (i *Interpreter) VisitReturnStmt(r *parser.Return) (interface{}, error) {
-	var value interface{}
+	var val value.Value = value.Nil
 	var err error
 	if r.Value != nil {
-		value, err = i.evaluate(r.Value)
+		val, err = i.evaluate(r.Value)
 	}
 
 	if err != nil {
@@ -105,7 +258,7 @@ func (i *Interpreter) VisitReturnStmt(r *parser.Return) (interface{}, error) {
 	}
 
 	return nil, &ReturnError{
-		Value: value,
+		Value: val,
 	}
 }
 
@@ -113,6 +266,11 @@ func (i *Interpreter) VisitLiteralExpr(l *parser.Literal) (interface{}, error) {
 	return l.Value, nil
 }
 
+// VisitLambdaExpr Creates a closure over the current environment, like a function declaration
+func (i *Interpreter) VisitLambdaExpr(l *parser.Lambda) (interface{}, error) {
+	return NewLoxFunction(l.Function, i.Environment, i.module, false), nil
+}
+
 func (i *Interpreter) VisitGroupingExpr(g *parser.Grouping) (interface{}, error) {
 	return i.evaluate(g.Expression)
 }
@@ -125,19 +283,16 @@ func (i *Interpreter) VisitUnaryExpr(u *parser.Unary) (interface{}, error) {
 
 	switch u.Operator.TokenType {
 	case token.MINUS:
-		err := i.checkNumberOperand(u.Operator, u.Right)
+		number, err := i.checkNumberOperand(u.Operator, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			Value_float: -right.(token.Object).Value_float,
-			ObjType:     token.NUMBER_TYPE,
-		}, nil
+		return value.Number(-number), nil
 	case token.BANG:
-		return i.isTruthy(u.Right)
+		return value.Bool(!i.isTruthy(right)), nil
 	}
 
-	return nil, &RuntimeError{
+	return nil, &utils.RuntimeError{
 		Token: u.Operator,
 		Err:   errors.New("undefined unary token"),
 	}
@@ -149,26 +304,113 @@ func (i *Interpreter) VisitAssignExpr(a *parser.Assign) (interface{}, error) {
 		return nil, err
 	}
 
-	err = i.Environment.Assign(a.Name, val)
+	if l, ok := i.locals[a]; ok {
+		i.Environment.AssignAt(l.depth, l.slot, val)
+		return val, nil
+	}
+
+	err = i.globals.Assign(a.Name, val)
 	return val, err
 }
 
-func (i *Interpreter) VisitLogicalExpr(l *parser.Logical) (interface{}, error) {
-	left, err := i.evaluate(l.Left)
+func (i *Interpreter) VisitGetExpr(g *parser.Get) (interface{}, error) {
+	object, err := i.evaluate(g.Object)
 	if err != nil {
 		return nil, err
 	}
 
-	val, err := i.isObjectTruthy(left.(token.Object))
+	switch obj := object.(type) {
+	case *LoxInstance:
+		return obj.Get(g.Name)
+	case *Module:
+		return obj.Get(g.Name)
+	case *LoxError:
+		return obj.Get(g.Name)
+	}
+
+	return nil, &utils.RuntimeError{
+		Token: g.Name,
+		Err:   errors.New("Only instances, modules and errors have properties."),
+	}
+}
+
+func (i *Interpreter) VisitSetExpr(s *parser.Set) (interface{}, error) {
+	object, err := i.evaluate(s.Object)
 	if err != nil {
 		return nil, err
 	}
+	// The value is evaluated before the object is checked, as the VM does
+	val, err := i.evaluate(s.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := object.(*LoxInstance)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: s.Name,
+			Err:   errors.New("Only instances have fields."),
+		}
+	}
+
+	instance.Set(s.Name, val)
+	return val, nil
+}
+
+func (i *Interpreter) VisitSuperExpr(s *parser.Super) (interface{}, error) {
+	distance := i.locals[s].depth
+	val, err := i.Environment.GetAt(distance, 0, s.Keyword)
+	if err != nil {
+		return nil, err
+	}
+	superclass, ok := val.(*LoxClass)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: s.Keyword,
+			Err:   errors.New("Superclass must be a class."),
+		}
+	}
+
+	// "this" is always in the environment just inside the one holding "super"
+	val, err = i.Environment.GetAt(distance-1, 0, token.NewToken(token.THIS, "this", nil, s.Keyword.Line, s.Keyword.Column))
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := val.(*LoxInstance)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: s.Keyword,
+			Err:   errors.New("Can't use 'super' without an instance."),
+		}
+	}
+
+	method, ok := superclass.FindMethod(s.Method.Lexeme)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: s.Method,
+			Err:   errors.New("Undefined property '" + s.Method.Lexeme + "'."),
+		}
+	}
+
+	return method.Bind(instance), nil
+}
+
+func (i *Interpreter) VisitThisExpr(t *parser.This) (interface{}, error) {
+	return i.lookUpVariable(t.Keyword, t)
+}
+
+func (i *Interpreter) VisitLogicalExpr(l *parser.Logical) (interface{}, error) {
+	left, err := i.evaluate(l.Left)
+	if err != nil {
+		return nil, err
+	}
+
 	if l.Operator.TokenType == token.OR {
-		if val.Value_bool {
+		if i.isTruthy(left) {
 			return left, nil
 		}
 	} else {
-		if !val.Value_bool {
+		if !i.isTruthy(left) {
 			return left, nil
 		}
 	}
@@ -178,26 +420,40 @@ func (i *Interpreter) VisitLogicalExpr(l *parser.Logical) (interface{}, error) {
 
 func (i *Interpreter) VisitWhileStmt(w *parser.While) (interface{}, error) {
 	for {
-		res, err := i.isTruthy(w.Condition)
+		condition, err := i.evaluate(w.Condition)
 		if err != nil {
 			return nil, err
 		}
-		if res.(token.Object).Value_bool == false {
+		if !i.isTruthy(condition) {
 			break
 		}
-		_, err = i.execute(w.Body)
+
+		signal, err := i.execute(w.Body)
 		if err != nil {
 			return nil, err
 		}
+		if signal == BREAK_SIGNAL {
+			break
+		}
+
+		// Increment of a desugared for loop runs even after a continue
+		if w.Increment != nil {
+			_, err = i.evaluate(w.Increment)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return nil, nil
 }
 
 func (i *Interpreter) VisitVariableExpr(v *parser.Variable) (interface{}, error) {
-	return i.Environment.Get(v.Name)
+	return i.lookUpVariable(v.Name, v)
 }
 
+// VisitBinaryExpr Both operands are evaluated exactly once, left to right,
+// and the operators work on the computed values
 func (i *Interpreter) VisitBinaryExpr(b *parser.Binary) (interface{}, error) {
 	left, err := i.evaluate(b.Left)
 	if err != nil {
@@ -211,104 +467,79 @@ func (i *Interpreter) VisitBinaryExpr(b *parser.Binary) (interface{}, error) {
 
 	switch b.Operator.TokenType {
 	case token.GREATER:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: left.(token.Object).Value_float > right.(token.Object).Value_float,
-		}, nil
+		return value.Bool(l > r), nil
 	case token.GREATER_EQUAL:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: left.(token.Object).Value_float >= right.(token.Object).Value_float,
-		}, nil
+		return value.Bool(l >= r), nil
 	case token.LESS:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: left.(token.Object).Value_float < right.(token.Object).Value_float,
-		}, nil
+		return value.Bool(l < r), nil
 	case token.LESS_EQUAL:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: left.(token.Object).Value_float <= right.(token.Object).Value_float,
-		}, nil
+		return value.Bool(l <= r), nil
 	case token.MINUS:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			ObjType:     token.NUMBER_TYPE,
-			Value_float: left.(token.Object).Value_float - right.(token.Object).Value_float,
-		}, nil
+		return value.Number(l - r), nil
 	case token.PLUS:
-		leftType := left.(token.Object).ObjType
-		rightType := right.(token.Object).ObjType
-		if leftType == token.NUMBER_TYPE && rightType == token.NUMBER_TYPE {
-			return token.Object{
-				ObjType:     token.NUMBER_TYPE,
-				Value_float: left.(token.Object).Value_float + right.(token.Object).Value_float,
-			}, nil
-		} else if leftType == token.STRING_TYPE && rightType == token.STRING_TYPE {
-			return token.Object{
-				ObjType:   token.STRING_TYPE,
-				Value_str: left.(token.Object).Value_str + right.(token.Object).Value_str,
-			}, nil
-		}
-
-		return nil, &RuntimeError{
+		if l, ok := value.AsNumber(left); ok {
+			if r, ok := value.AsNumber(right); ok {
+				return value.Number(l + r), nil
+			}
+		}
+		if l, ok := value.AsString(left); ok {
+			if r, ok := value.AsString(right); ok {
+				if max := i.limits.MaxStringLength; max > 0 && len(l)+len(r) > max {
+					return nil, &utils.RuntimeError{Token: b.Operator, Err: ErrStringLimit}
+				}
+				return value.String(l + r), nil
+			}
+		}
+
+		return nil, &utils.RuntimeError{
 			Token: b.Operator,
-			Err:   errors.New("Operands must be a number."),
+			Err:   errors.New("Operands must be two numbers or two strings."),
 		}
 	case token.SLASH:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		if right.(token.Object).Value_float == 0 {
-			return nil, &RuntimeError{
+		if r == 0 {
+			return nil, &utils.RuntimeError{
 				Token: b.Operator,
 				Err:   errors.New("cannot divide by 0"),
 			}
 		}
-		return token.Object{
-			ObjType:     token.NUMBER_TYPE,
-			Value_float: left.(token.Object).Value_float / right.(token.Object).Value_float,
-		}, nil
+		return value.Number(l / r), nil
 	case token.STAR:
-		err := i.checkNumberOperands(b.Operator, b.Left, b.Right)
+		l, r, err := i.checkNumberOperands(b.Operator, left, right)
 		if err != nil {
 			return nil, err
 		}
-		return token.Object{
-			ObjType:     token.NUMBER_TYPE,
-			Value_float: left.(token.Object).Value_float * right.(token.Object).Value_float,
-		}, nil
+		return value.Number(l * r), nil
 	case token.BANG_EQUAL:
-		val, err := i.isEqual(b.Left, b.Right)
-		return token.Object{
-			ObjType:    val.(token.Object).ObjType,
-			Value_bool: !val.(token.Object).Value_bool,
-		}, err
+		return value.Bool(!i.isEqual(left, right)), nil
 	case token.EQUAL_EQUAL:
-		return i.isEqual(b.Left, b.Right)
+		return value.Bool(i.isEqual(left, right)), nil
 	}
 
-	return nil, &RuntimeError{
+	return nil, &utils.RuntimeError{
 		Token: b.Operator,
 		Err:   errors.New("undefined binary token"),
 	}
@@ -320,7 +551,12 @@ func (i *Interpreter) VisitCallExpr(c *parser.Call) (interface{}, error) {
 		return nil, err
 	}
 
-	arguments := make([]interface{}, 0)
+	// Natives created by the host are wrapped when they are called
+	if native, ok := callee.(*stdlib.NativeFunction); ok {
+		callee = Native(native)
+	}
+
+	arguments := make([]value.Value, 0)
 
 	for _, argument := range c.Arguments {
 		res, err := i.evaluate(argument)
@@ -331,20 +567,41 @@ func (i *Interpreter) VisitCallExpr(c *parser.Call) (interface{}, error) {
 		arguments = append(arguments, res)
 	}
 
-	switch callee.(type) {
+	switch function := callee.(type) {
 	case LoxCallable:
-		function := callee.(LoxCallable)
-		if len(arguments) != function.Arity() {
-			return nil, &RuntimeError{
+		if function.Arity() != stdlib.VARIADIC && len(arguments) != function.Arity() {
+			return nil, &utils.RuntimeError{
 				Token: c.Paren,
 				Err:   errors.New("Expected " + strconv.Itoa(function.Arity()) + " arguments but got " + strconv.Itoa(len(arguments)) + "."),
 			}
 		}
 
-		return function.Call(i, arguments)
+		err := i.enterCall(c.Paren, callFrame{
+			function: callableName(function),
+			callSite: c.Paren,
+			file:     i.module.File,
+		})
+		if err != nil {
+			return nil, i.withStackTrace(err)
+		}
+		defer i.callStack.Pop()
+
+		result, err := function.Call(i, arguments)
+		if err != nil {
+			// Native functions don't know where they were called from
+			runtimeError := &utils.RuntimeError{}
+			if _, native := function.(*nativeFunction); native && !errors.As(err, &runtimeError) {
+				err = &utils.RuntimeError{Token: c.Paren, Err: err}
+			}
+			return nil, i.withStackTrace(err)
+		}
+		if err := i.checkString(c.Paren, result); err != nil {
+			return nil, i.withStackTrace(err)
+		}
+		return result, nil
 	}
 
-	return nil, &RuntimeError{
+	return nil, &utils.RuntimeError{
 		Token: c.Paren,
 		Err:   errors.New("Can only call functions and classes"),
 	}
@@ -356,190 +613,84 @@ func (i *Interpreter) VisitIfStmt(pif *parser.If) (interface{}, error) {
 		return nil, err
 	}
 
-	if val.(token.Object).Value_bool {
-		_, err = i.execute(pif.ThenBranch)
-		if err != nil {
-			return nil, err
-		}
-
+	// Result is passed up so break and continue reach the loop
+	if i.isTruthy(val) {
+		return i.execute(pif.ThenBranch)
 	} else if pif.ElseBranch != nil {
-		_, err = i.execute(pif.ElseBranch)
-		if err != nil {
-			return nil, err
-		}
+		return i.execute(pif.ElseBranch)
 	}
 
 	return nil, nil
 }
 
-func (i *Interpreter) isTruthy(e parser.Expr) (interface{}, error) {
-	utils.DPrintf("isTruthy -> %+v\n", e)
-	v, err := e.Visit(i)
-	if err != nil {
-		return nil, err
-	}
-	switch v.(type) {
-	case token.Object:
-		return i.isObjectTruthy(v.(token.Object))
-	}
-
-	return nil, errors.New("undefined type for truthy")
-}
-
-func (i *Interpreter) isObjectTruthy(o token.Object) (token.Object, error) {
-	switch o.ObjType {
-	case token.BOOL_TYPE:
-		return o, nil
-	case token.STRING_TYPE:
-		if len(o.Value_str) > 0 {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: true,
-			}, nil
-		} else {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: false,
-			}, nil
-		}
-	case token.NUMBER_TYPE:
-		if o.Value_float != 0 {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: true,
-			}, nil
-		} else {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: false,
-			}, nil
-		}
-	case token.NOT_ASSIGNED_TYPE:
-		return token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: false,
-		}, nil
-
-	}
-
-	return token.Object{
-		ObjType:    token.BOOL_TYPE,
-		Value_bool: false,
-	}, nil
-}
-
-func (i *Interpreter) isEqual(a, b parser.Expr) (interface{}, error) {
-	v1, err := a.Visit(i)
-	if err != nil {
-		return nil, err
-	}
-	v2, err := b.Visit(i)
+func (i *Interpreter) isTruthy(v value.Value) bool {
+	utils.DPrintf("isTruthy -> %+v\n", v)
+	return value.IsTruthy(v)
+}
+
+func (i *Interpreter) isEqual(a, b value.Value) bool {
+	utils.DPrintf("isEqual -> %+v\n%+v\n", a, b)
+	return value.Equal(a, b)
+}
+
+// evaluate Evaluates the expression into a runtime value
+func (i *Interpreter) evaluate(e parser.Expr) (value.Value, error) {
+	val, err := e.Visit(i)
 	if err != nil {
 		return nil, err
 	}
 
-	utils.DPrintf("isEqual -> %+v\n%+v\n", v1, v2)
-	switch v1.(token.Object).ObjType {
-	case token.NUMBER_TYPE:
-		if v2.(token.Object).ObjType == token.NUMBER_TYPE {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: v1.(token.Object).Value_float == v2.(token.Object).Value_float,
-			}, nil
-		}
-
-	case token.STRING_TYPE:
-		if v2.(token.Object).ObjType == token.STRING_TYPE {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: v1.(token.Object).Value_str == v2.(token.Object).Value_str,
-			}, nil
-		}
-	case token.BOOL_TYPE:
-		if v2.(token.Object).ObjType == token.BOOL_TYPE {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: v1.(token.Object).Value_bool == v2.(token.Object).Value_bool,
-			}, nil
-		}
-
-	case token.NOT_ASSIGNED_TYPE:
-		if v2.(token.Object).ObjType == token.NOT_ASSIGNED_TYPE {
-			return token.Object{
-				ObjType:    token.BOOL_TYPE,
-				Value_bool: true,
-			}, nil
-		}
+	v, ok := val.(value.Value)
+	if !ok || v == nil {
+		return value.Nil, nil
 	}
-
-	return token.Object{
-		ObjType:    token.BOOL_TYPE,
-		Value_bool: false,
-	}, nil
-}
-
-func (i *Interpreter) evaluate(e parser.Expr) (interface{}, error) {
-	return e.Visit(i)
+	return v, nil
 }
 
-func (i *Interpreter) checkNumberOperand(operator token.Token, operand parser.Expr) error {
+// checkNumberOperand Returns the operand as a number or a RuntimeError
+func (i *Interpreter) checkNumberOperand(operator token.Token, operand value.Value) (float64, error) {
 	utils.DPrintf("%+v %+v\n", operator, operand)
-	v, err := operand.Visit(i)
-	if err != nil {
-		return &RuntimeError{
-			Token: operator,
-			Err:   err,
-		}
-	}
-	if v.(token.Object).ObjType == token.NUMBER_TYPE {
-		return nil
+	if number, ok := value.AsNumber(operand); ok {
+		return number, nil
 	}
 
-	return &RuntimeError{
+	return 0, &utils.RuntimeError{
 		Token: operator,
 		Err:   errors.New("Operand must be a number."),
 	}
 }
 
-func (i *Interpreter) checkNumberOperands(operator token.Token, left, right parser.Expr) error {
+// checkNumberOperands Returns both operands as numbers or a RuntimeError
+func (i *Interpreter) checkNumberOperands(operator token.Token, left, right value.Value) (float64, float64, error) {
 	utils.DPrintf("%+v %+v %+v\n", operator, left, right)
-	type1, err := left.Visit(i)
-	if err != nil {
-		return &RuntimeError{
-			Token: operator,
-			Err:   err,
-		}
-	}
-
-	type2, err := right.Visit(i)
-	if err != nil {
-		return &RuntimeError{
-			Token: operator,
-			Err:   err,
-		}
-	}
-
-	if type1.(token.Object).ObjType == token.NUMBER_TYPE && type2.(token.Object).ObjType == token.NUMBER_TYPE {
-		return nil
+	l, leftOk := value.AsNumber(left)
+	r, rightOk := value.AsNumber(right)
+	if leftOk && rightOk {
+		return l, r, nil
 	}
 
-	return &RuntimeError{
+	return 0, 0, &utils.RuntimeError{
 		Token: operator,
-		Err:   errors.New("Operands must be a number."),
+		Err:   errors.New("Operands must be numbers."),
 	}
 }
 
-func (i *Interpreter) executeBlock(stmts []parser.Stmt, env *environment.Environment) error {
+// executeBlock Executes the statements in the environment.
+// A break or continue stops the block and its signal is returned
+func (i *Interpreter) executeBlock(stmts []parser.Stmt, env *environment.Environment) (interface{}, error) {
 	previous := i.Environment
 	defer func() { i.Environment = previous }()
 
 	i.Environment = env
 	for _, stmt := range stmts {
-		_, err := i.execute(stmt)
+		result, err := i.execute(stmt)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if signal, ok := result.(loopSignal); ok {
+			return signal, nil
 		}
 	}
 
-	return nil
+	return nil, nil
 }
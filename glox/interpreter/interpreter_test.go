@@ -0,0 +1,602 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// run Scans, parses, resolves and executes the source.
+// The error of the first failing statement is returned
+func run(t *testing.T, source string) (*Interpreter, error) {
+	t.Helper()
+	return runAs(t, "script", source)
+}
+
+// runAs Runs the source as if it was read from the file,
+// looking for imported modules in the search path
+func runAs(t *testing.T, fileName, source string, searchPath ...string) (*Interpreter, error) {
+	t.Helper()
+	return runWith(t, source, func(i *Interpreter) {
+		i.SetFileName(fileName)
+		i.SetSearchPath(searchPath)
+	})
+}
+
+// runWith Runs the source after setup has configured the interpreter
+func runWith(t *testing.T, source string, setup func(i *Interpreter)) (*Interpreter, error) {
+	t.Helper()
+	diag := diagnostics.NewCollector()
+
+	tokens := scanner.NewScanner(source, diag).ScanTokens()
+	stmts, err := parser.NewParser(tokens, diag).Parse()
+	if err != nil {
+		t.Fatalf("parse error in %q: %v", source, err)
+	}
+
+	i := NewInterpreter(diag)
+	setup(i)
+	resolver.NewResolver(i, diag).Resolve(stmts)
+	if diag.HasErrors() {
+		t.Fatalf("resolve error in %q: %v", source, diag.Diagnostics())
+	}
+
+	for _, stmt := range stmts {
+		if _, err := i.execute(stmt); err != nil {
+			return i, err
+		}
+	}
+	return i, nil
+}
+
+func global(t *testing.T, i *Interpreter, name string) value.Value {
+	t.Helper()
+	val, err := i.globals.Get(token.NewToken(token.IDENTIFIER, name, nil, 0, 0))
+	if err != nil {
+		t.Fatalf("global %s: %v", name, err)
+	}
+	return val
+}
+
+// sideEffects Declares counters and two functions which count
+// how many times they are called before returning the given operands
+func sideEffects(left, right string) string {
+	return `
+var leftCalls = 0;
+var rightCalls = 0;
+fun l() { leftCalls = leftCalls + 1; return ` + left + `; }
+fun r() { rightCalls = rightCalls + 1; return ` + right + `; }
+`
+}
+
+func TestBinaryOperandsEvaluatedOnce(t *testing.T) {
+	tests := []struct {
+		operator string
+		left     string
+		right    string
+		want     value.Value
+	}{
+		{"+", "1", "2", value.Number(3)},
+		{"+", `"a"`, `"b"`, value.String("ab")},
+		{"-", "5", "2", value.Number(3)},
+		{"*", "3", "4", value.Number(12)},
+		{"/", "8", "2", value.Number(4)},
+		{">", "2", "1", value.Bool(true)},
+		{">=", "1", "1", value.Bool(true)},
+		{"<", "2", "1", value.Bool(false)},
+		{"<=", "1", "2", value.Bool(true)},
+		{"==", "1", "1", value.Bool(true)},
+		{"==", `"a"`, "1", value.Bool(false)},
+		{"!=", "1", "2", value.Bool(true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.left+tt.operator+tt.right, func(t *testing.T) {
+			i, err := run(t, sideEffects(tt.left, tt.right)+"var result = l() "+tt.operator+" r();")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := global(t, i, "leftCalls"); got != value.Number(1) {
+				t.Errorf("left operand evaluated %v times, want 1", got)
+			}
+			if got := global(t, i, "rightCalls"); got != value.Number(1) {
+				t.Errorf("right operand evaluated %v times, want 1", got)
+			}
+			if got := global(t, i, "result"); !value.Equal(got, tt.want) {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinaryOperandsEvaluatedOnceOnTypeError(t *testing.T) {
+	operators := []string{"+", "-", "*", "/", ">", ">=", "<", "<="}
+	for _, operator := range operators {
+		t.Run(operator, func(t *testing.T) {
+			i, err := run(t, sideEffects("1", "true")+"l() "+operator+" r();")
+			if err == nil {
+				t.Fatalf("expected a runtime error")
+			}
+			if _, ok := err.(*utils.RuntimeError); !ok {
+				t.Errorf("error is %T, want *utils.RuntimeError", err)
+			}
+
+			if got := global(t, i, "leftCalls"); got != value.Number(1) {
+				t.Errorf("left operand evaluated %v times, want 1", got)
+			}
+			if got := global(t, i, "rightCalls"); got != value.Number(1) {
+				t.Errorf("right operand evaluated %v times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestUnaryOperandEvaluatedOnce(t *testing.T) {
+	tests := []struct {
+		operator string
+		operand  string
+		want     value.Value
+	}{
+		{"-", "3", value.Number(-3)},
+		{"!", "true", value.Bool(false)},
+		{"!", "false", value.Bool(true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operator+tt.operand, func(t *testing.T) {
+			i, err := run(t, sideEffects(tt.operand, "nil")+"var result = "+tt.operator+"l();")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := global(t, i, "leftCalls"); got != value.Number(1) {
+				t.Errorf("operand evaluated %v times, want 1", got)
+			}
+			if got := global(t, i, "result"); !value.Equal(got, tt.want) {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogicalOperandsEvaluatedAtMostOnce(t *testing.T) {
+	tests := []struct {
+		operator   string
+		left       string
+		rightCalls value.Number
+		want       value.Value
+	}{
+		{"and", "true", 1, value.Bool(false)},
+		{"and", "false", 0, value.Bool(false)},
+		{"or", "true", 0, value.Bool(true)},
+		{"or", "false", 1, value.Bool(false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.left+" "+tt.operator, func(t *testing.T) {
+			i, err := run(t, sideEffects(tt.left, "false")+"var result = l() "+tt.operator+" r();")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := global(t, i, "leftCalls"); got != value.Number(1) {
+				t.Errorf("left operand evaluated %v times, want 1", got)
+			}
+			if got := global(t, i, "rightCalls"); got != tt.rightCalls {
+				t.Errorf("right operand evaluated %v times, want %v", got, tt.rightCalls)
+			}
+			if got := global(t, i, "result"); !value.Equal(got, tt.want) {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignmentInOperandRunsOnce(t *testing.T) {
+	i, err := run(t, `
+var a = 1;
+var result = (a = a + 1) + (a = a * 10);
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := global(t, i, "a"); got != value.Number(20) {
+		t.Errorf("a = %v, want 20", got)
+	}
+	if got := global(t, i, "result"); got != value.Number(22) {
+		t.Errorf("result = %v, want 22", got)
+	}
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   value.Value
+	}{
+		{"break in while", `var result = 0; while (true) { result = result + 1; if (result == 3) break; }`, value.Number(3)},
+		{"continue in while", `var result = 0; var i = 0; while (i < 5) { i = i + 1; if (i == 2) continue; result = result + i; }`, value.Number(13)},
+		{"break in for", `var result = 0; for (var i = 0; i < 10; i = i + 1) { if (i == 4) break; result = result + i; }`, value.Number(6)},
+		{"continue runs increment", `var result = 0; for (var i = 0; i < 5; i = i + 1) { if (i == 1) continue; result = result + i; }`, value.Number(9)},
+		{"break inner loop only", `var result = 0; for (var i = 0; i < 3; i = i + 1) { for (;;) { break; } result = result + 1; }`, value.Number(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := global(t, i, "result"); !value.Equal(got, tt.want) {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalSlots(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"shadowing", `var result = []; var a = "g"; { var a = "o"; var b = "b"; { var a = "i"; push(result, a + b); } push(result, a); } push(result, a);`, "[ib, o, g]"},
+		{"parameters and locals", `fun f(a, b) { var c = a + b; { var d = c * 2; return [a, b, c, d]; } } var result = f(1, 2);`, "[1, 2, 3, 6]"},
+		{"assign in enclosing scope", `var result; { var a = 1; var b = 2; { b = b + 10; a = b; } result = [a, b]; }`, "[12, 12]"},
+		{"closure sees later assignment", `var result; { var n = 1; fun get() { return n; } n = 5; result = get(); }`, "5"},
+		{"local recursion", `var result; { fun fact(n) { if (n < 2) return 1; return n * fact(n - 1); } result = fact(5); }`, "120"},
+		{"local class", `var result; { var x = 1; class A { make() { return A(); } } class B < A { get() { return super.make(); } } result = B().get(); }`, "A instance"},
+		{"catch variable", `var result; { var a = "a"; try { throw "e"; } catch (e) { var b = "b"; result = a + e + b; } }`, "aeb"},
+		{"loop variable", `var result = []; { var base = 10; for (x in [1, 2]) { var y = x + base; push(result, y); } }`, "[11, 12]"},
+		{"late bound global", `fun f() { return later; } var later = "late"; var result = f();`, "late"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := value.ToString(global(t, i, "result")); got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLists(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"literal", `var result = [1, "a", nil];`, "[1, a, nil]"},
+		{"index", `var xs = [1, 2, 3]; var result = xs[1];`, "2"},
+		{"assign index", `var xs = [1, 2, 3]; xs[0] = 5; var result = xs;`, "[5, 2, 3]"},
+		{"shared reference", `var xs = []; var ys = xs; push(ys, 1); var result = xs;`, "[1]"},
+		{"len", `var result = len([1, 2, 3]);`, "3"},
+		{"pop", `var xs = [1, 2]; var result = pop(xs) + len(xs);`, "3"},
+		{"slice", `var result = slice([1, 2, 3, 4], 1, 3);`, "[2, 3]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := value.ToString(global(t, i, "result")); got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	sources := []string{
+		`[1, 2][2];`,
+		`[1, 2][-1];`,
+		`[1, 2][0.5];`,
+		`var xs = [1]; xs[1] = 2;`,
+		`"ab"[0];`,
+		`pop([]);`,
+		`slice([1, 2], 1, 3);`,
+	}
+
+	for _, source := range sources {
+		t.Run(source, func(t *testing.T) {
+			_, err := run(t, source)
+			if _, ok := err.(*utils.RuntimeError); !ok {
+				t.Errorf("error is %T, want *utils.RuntimeError", err)
+			}
+		})
+	}
+}
+
+func TestMaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"literal", `var result = {"a": 1, 2: nil};`, "{a: 1, 2: nil}"},
+		{"empty literal", `var result = {};`, "{}"},
+		{"lookup", `var m = {"a": 1}; var result = m["a"];`, "1"},
+		{"assign", `var m = {"a": 1}; m["a"] = 2; m["b"] = 3; var result = m;`, "{a: 2, b: 3}"},
+		{"keys by value", `var m = {}; m[1] = "x"; m["s"] = "y"; m[nil] = "z"; var result = m[1] + m["s"] + m[nil];`, "xyz"},
+		{"objects by identity", `class A {} var a = A(); var m = {}; m[a] = 1; m[A()] = 2; var result = m[a];`, "1"},
+		{"has", `var result = has({"a": 1}, "a") and !has({"a": 1}, "b");`, "true"},
+		{"delete", `var m = {"a": 1, "b": 2}; delete(m, "a"); var result = m;`, "{b: 2}"},
+		{"keys", `var result = keys({"b": 1, "a": 2});`, "[b, a]"},
+		{"values", `var result = values({"b": 1, "a": 2});`, "[1, 2]"},
+		{"len", `var result = len({"b": 1, "a": 2});`, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := value.ToString(global(t, i, "result")); got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapMissingKey(t *testing.T) {
+	_, err := run(t, `var m = {"a": 1}; m["b"];`)
+	if _, ok := err.(*utils.RuntimeError); !ok {
+		t.Errorf("error is %T, want *utils.RuntimeError", err)
+	}
+}
+
+func TestForIn(t *testing.T) {
+	iterators := `
+class Counter {
+  init(n) { this.n = n; }
+  iter() { return CounterIter(this.n); }
+}
+class CounterIter {
+  init(n) { this.i = 0; this.n = n; }
+  hasNext() { return this.i < this.n; }
+  next() { this.i = this.i + 1; return this.i; }
+}
+`
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"string", `var result = []; for (c in "abc") push(result, c);`, "[a, b, c]"},
+		{"list", `var result = []; for (x in [1, 2, 3]) push(result, x * 2);`, "[2, 4, 6]"},
+		{"map keys", `var result = []; for (k in {"a": 1, "b": 2}) push(result, k);`, "[a, b]"},
+		{"range", `var result = []; for (n in range(2, 5)) push(result, n);`, "[2, 3, 4]"},
+		{"instance", iterators + `var result = []; for (v in Counter(3)) push(result, v);`, "[1, 2, 3]"},
+		{"break and continue", `var result = []; for (x in range(0, 10)) { if (x == 1) continue; if (x == 4) break; push(result, x); }`, "[0, 2, 3]"},
+		{"fresh variable per iteration", `var fs = []; for (x in [1, 2]) { fun f() { return x; } push(fs, f); } var result = fs[0]() + fs[1]();`, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := value.ToString(global(t, i, "result")); got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeFiles Creates the files under dir, keyed by their relative path
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/counter.lox": `
+var count = 0;
+fun increment() { count = count + 1; return count; }
+`,
+		"lib/uses.lox":      `import "counter.lox" as c; var first = c.increment();`,
+		"search/shared.lox": `var name = "shared";`,
+	})
+
+	i, err := runAs(t, filepath.Join(dir, "main.lox"), `
+import "lib/counter.lox" as counter;
+import "lib/uses.lox" as uses;
+import "shared.lox" as shared;
+var count = 100;
+counter.increment();
+var result = [counter.count, uses.first, count, shared.name];
+`, filepath.Join(dir, "search"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The module runs once and keeps its own globals
+	if got := value.ToString(global(t, i, "result")); got != "[2, 1, 100, shared]" {
+		t.Errorf("result = %v, want [2, 1, 100, shared]", got)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.lox": `import "b.lox" as b;`,
+		"b.lox": `import "a.lox" as a;`,
+		"m.lox": `var x = 1;`,
+	})
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"missing module", `import "missing.lox" as m;`},
+		{"cycle", `import "a.lox" as a;`},
+		{"undefined member", `import "m.lox" as m; m.y;`},
+		{"natives are not members", `import "m.lox" as m; m.len;`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runAs(t, filepath.Join(dir, "main.lox"), tt.source)
+			if _, ok := err.(*utils.RuntimeError); !ok {
+				t.Errorf("error is %T, want *utils.RuntimeError", err)
+			}
+		})
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"catch thrown value", `var result; try { throw "oops"; } catch (e) { result = e; }`, "oops"},
+		{"catch division by zero", `var result; try { 1 / 0; } catch (e) { result = e.message; }`, "cannot divide by 0"},
+		{"catch undefined variable", `var result; try { missing; } catch (e) { result = e.line; }`, "1"},
+		{"catch arity mismatch", `fun f(a) {} var result; try { f(); } catch (e) { result = e.message; }`, "Expected 1 arguments but got 0."},
+		{"error object stack", `fun f() { throw Error("x"); } var result; try { f(); } catch (e) { result = len(e.stack); }`, "2"},
+		{"finally after catch", `var result = []; try { throw 1; } catch (e) { push(result, e); } finally { push(result, 2); }`, "[1, 2]"},
+		{"finally without catch", `var result = []; try { try { throw 1; } finally { push(result, "f"); } } catch (e) { push(result, e); }`, "[f, 1]"},
+		{"finally runs on return", `var result = []; fun f() { try { return 1; } finally { push(result, "f"); } } push(result, f());`, "[f, 1]"},
+		{"return in finally wins", `fun f() { try { return 1; } finally { return 2; } } var result = f();`, "2"},
+		{"finally runs on break and continue", `var result = []; for (x in range(0, 5)) { try { if (x == 1) continue; if (x == 3) break; } finally { push(result, x); } }`, "[0, 1, 2, 3]"},
+		{"rethrow from catch", `var result; try { try { throw 1; } catch (e) { throw e + 1; } } catch (e) { result = e; }`, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := value.ToString(global(t, i, "result")); got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	_, err := run(t, `throw "oops";`)
+	runtimeError, ok := err.(*utils.RuntimeError)
+	if !ok {
+		t.Fatalf("error is %T, want *utils.RuntimeError", err)
+	}
+	if got := runtimeError.Err.Error(); got != "Uncaught oops" {
+		t.Errorf("message = %q, want %q", got, "Uncaught oops")
+	}
+}
+
+func TestLambdas(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"fun expression", `var add = fun (a, b) { return a + b; }; var result = add(1, 2);`, "3"},
+		{"arrow", `var square = (x) => x * x; var result = square(4);`, "16"},
+		{"arrow without parameters", `var result = (() => "ok")();`, "ok"},
+		{"immediately invoked", `var result; fun (x) { result = x; }(5);`, "5"},
+		{"callback", `fun apply(f, x) { return f(x); } var result = apply((x) => x + 1, 1);`, "2"},
+		{"closure", `fun counter() { var n = 0; return () => n = n + 1; } var c = counter(); c(); var result = c();`, "2"},
+		{"this in method", `class A { init() { this.v = 3; } get() { return () => this.v; } } var result = A().get()();`, "3"},
+		{"string", `var result = fun () {};`, "<fn anonymous>"},
+		{"grouping still parses", `var x = 2; var result = (x) * 3;`, "6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := run(t, tt.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := value.ToString(global(t, i, "result")); got != tt.want {
+				t.Errorf("result = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		limits Limits
+		want   error
+	}{
+		{"step limit", `while (true) {}`, Limits{MaxSteps: 1000}, ErrStepLimit},
+		{"step limit is not caught", `try { while (true) {} } catch (e) {}`, Limits{MaxSteps: 1000}, ErrStepLimit},
+		{"deep recursion", `fun f(n) { return f(n + 1); } f(0);`, Limits{}, ErrStackLimit},
+		{"call depth", `fun f(n) { if (n > 0) f(n - 1); } f(10);`, Limits{MaxCallDepth: 5}, ErrStackLimit},
+		{"string concatenation", `var s = "ab"; while (true) s = s + s;`, Limits{MaxStringLength: 64}, ErrStringLimit},
+		{"string from native", `join(["abc", "def"], "");`, Limits{MaxStringLength: 4}, ErrStringLimit},
+		{"string is not caught", `try { "abc" + "def"; } catch (e) {}`, Limits{MaxStringLength: 4}, ErrStringLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runWith(t, tt.source, func(i *Interpreter) {
+				i.SetLimits(tt.limits)
+			})
+			if _, ok := err.(*utils.RuntimeError); !ok {
+				t.Fatalf("error is %T, want *utils.RuntimeError", err)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStackOverflowIsCaught(t *testing.T) {
+	i, err := run(t, `fun f() { f(); } var result; try { f(); } catch (e) { result = e.message; }`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := value.ToString(global(t, i, "result")); got != ErrStackLimit.Error() {
+		t.Errorf("result = %v, want %v", got, ErrStackLimit)
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := runWith(t, `while (true) { try {} catch (e) {} }`, func(i *Interpreter) {
+		i.SetContext(ctx)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
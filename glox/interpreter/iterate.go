@@ -0,0 +1,92 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/environment"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+func (i *Interpreter) VisitForInStmt(f *parser.ForIn) (interface{}, error) {
+	iterable, err := i.evaluate(f.Iterable)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := value.Iterate(iterable, func(instance value.Value, name string) (value.Value, error) {
+		return i.callMethod(f.In, instance, name)
+	})
+	if err == value.ErrNotIterable {
+		return nil, &utils.RuntimeError{Token: f.In, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		val, ok, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		// Every iteration gets its own variable so closures capture the current value
+		env := environment.NewEnvironment(i.Environment)
+		env.Define(f.Name.Lexeme, val)
+		signal, err := i.executeBlock([]parser.Stmt{f.Body}, env)
+		if err != nil {
+			return nil, err
+		}
+		if signal == BREAK_SIGNAL {
+			break
+		}
+	}
+
+	return nil, nil
+}
+
+// callMethod Calls the method of the instance without arguments.
+// at is the position the call is reported at in stack traces
+func (i *Interpreter) callMethod(at token.Token, receiver value.Value, name string) (value.Value, error) {
+	instance, ok := receiver.(*LoxInstance)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: at,
+			Err:   errors.New("Only instances have methods."),
+		}
+	}
+	method, ok := instance.Class.FindMethod(name)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: at,
+			Err:   errors.New(instance.String() + " has no method '" + name + "'."),
+		}
+	}
+	if method.Arity() != 0 {
+		return nil, &utils.RuntimeError{
+			Token: at,
+			Err:   errors.New("Method '" + name + "' must take no arguments."),
+		}
+	}
+
+	err := i.enterCall(at, callFrame{
+		function: name,
+		callSite: at,
+		file:     i.module.File,
+	})
+	if err != nil {
+		return nil, i.withStackTrace(err)
+	}
+	defer i.callStack.Pop()
+
+	result, err := method.Bind(instance).Call(i, nil)
+	if err != nil {
+		return nil, i.withStackTrace(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// DEFAULT_MAX_CALL_DEPTH Calls which may be active at once when no limit is set.
+// Deeper recursion is reported as a stack overflow before Go runs out of stack
+const DEFAULT_MAX_CALL_DEPTH = 2048
+
+var (
+	ErrStepLimit   = errors.New("Step limit exceeded.")
+	ErrStackLimit  = errors.New("Stack overflow.")
+	ErrStringLimit = errors.New("String size limit exceeded.")
+)
+
+// Limits Bounds the work a script may do. Zero means unlimited,
+// except for MaxCallDepth which then uses DEFAULT_MAX_CALL_DEPTH
+type Limits struct {
+	// Statements which may be executed
+	MaxSteps int
+	// Calls which may be active at once
+	MaxCallDepth int
+	// Bytes in a string created by the script
+	MaxStringLength int
+}
+
+// SetContext Sets the context which stops the script once it is done.
+// The step budget starts over for every context
+func (i *Interpreter) SetContext(ctx context.Context) {
+	i.ctx = ctx
+	i.done = ctx.Done()
+	i.steps = 0
+}
+
+func (i *Interpreter) SetLimits(limits Limits) {
+	if limits.MaxCallDepth <= 0 {
+		limits.MaxCallDepth = DEFAULT_MAX_CALL_DEPTH
+	}
+	i.limits = limits
+}
+
+// step Counts a step and returns an error once the budget
+// is spent or the context is done
+func (i *Interpreter) step() error {
+	i.steps++
+	if i.limits.MaxSteps > 0 && i.steps > i.limits.MaxSteps {
+		return ErrStepLimit
+	}
+	return i.interrupted()
+}
+
+// interrupted Returns the error of the context if it is done
+func (i *Interpreter) interrupted() error {
+	select {
+	case <-i.done:
+		return i.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// enterCall Pushes the frame of a call, failing if the stack is full or the context is done.
+// at is where the error is reported
+func (i *Interpreter) enterCall(at token.Token, frame callFrame) error {
+	if err := i.interrupted(); err != nil {
+		return &utils.RuntimeError{Token: at, Err: err}
+	}
+	if i.callStack.Length() >= i.limits.MaxCallDepth {
+		return &utils.RuntimeError{Token: at, Err: ErrStackLimit}
+	}
+
+	i.callStack.Push(frame)
+	return nil
+}
+
+// checkString Returns an error if the value is a string longer than the limit
+func (i *Interpreter) checkString(at token.Token, v value.Value) error {
+	if i.limits.MaxStringLength <= 0 {
+		return nil
+	}
+	if s, ok := value.AsString(v); ok && len(s) > i.limits.MaxStringLength {
+		return &utils.RuntimeError{Token: at, Err: ErrStringLimit}
+	}
+	return nil
+}
+
+// IsFatal Reports whether the error must stop the script, even inside a try
+func IsFatal(err error) bool {
+	return errors.Is(err, ErrStepLimit) ||
+		errors.Is(err, ErrStringLimit) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
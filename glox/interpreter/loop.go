@@ -0,0 +1,11 @@
+package interpreter
+
+// loopSignal Is returned as the result of executing a break or continue
+// statement. Blocks and if statements pass it up until the enclosing loop
+// handles it, so no error is used for ordinary control flow
+type loopSignal int
+
+const (
+	BREAK_SIGNAL loopSignal = iota + 1
+	CONTINUE_SIGNAL
+)
@@ -0,0 +1,196 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/environment"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Module A file which runs in its own global namespace.
+// The globals it defines are the members other files can use
+type Module struct {
+	Name    string
+	File    string
+	Globals *environment.Environment
+}
+
+func newModule(name, file string, builtins *environment.Environment) *Module {
+	return &Module{
+		Name:    name,
+		File:    file,
+		Globals: environment.NewGlobals(builtins),
+	}
+}
+
+// Get Returns the global defined by the module.
+// Native functions are not members of the module
+func (m *Module) Get(name token.Token) (value.Value, error) {
+	if val, ok := m.Globals.Contains(name); ok {
+		return val, nil
+	}
+
+	return nil, &utils.RuntimeError{
+		Token: name,
+		Err:   errors.New("Undefined member '" + name.Lexeme + "' of module '" + m.Name + "'."),
+	}
+}
+
+func (m *Module) Type() value.Type {
+	return value.MODULE_TYPE
+}
+
+func (m *Module) String() string {
+	return "<module " + m.Name + ">"
+}
+
+// SetSearchPath Sets the directories searched for modules
+// which are not found relative to the importing file
+func (i *Interpreter) SetSearchPath(paths []string) {
+	i.searchPath = paths
+}
+
+// enterModule Makes the module the one whose globals and file are in use.
+// The returned function restores the previous module
+func (i *Interpreter) enterModule(m *Module) func() {
+	previous := i.module
+	i.module, i.globals = m, m.Globals
+	return func() {
+		i.module, i.globals = previous, previous.Globals
+	}
+}
+
+func (i *Interpreter) VisitImportStmt(im *parser.Import) (interface{}, error) {
+	module, err := i.importModule(im.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Environment.Define(im.Name.Lexeme, module)
+	return nil, nil
+}
+
+// importModule Returns the module at the path of the import, executing it
+// the first time it is imported
+func (i *Interpreter) importModule(path token.Token) (*Module, error) {
+	name, _ := value.AsString(path.Literal)
+	file, ok := i.findModule(name)
+	if !ok {
+		return nil, &utils.RuntimeError{
+			Token: path,
+			Err:   errors.New("Can't find module '" + name + "'."),
+		}
+	}
+
+	key, err := filepath.Abs(file)
+	if err != nil {
+		key = file
+	}
+	if module, ok := i.modules[key]; ok {
+		return module, nil
+	}
+
+	for k := range i.importing {
+		if i.importing[k] == key {
+			cycle := append(append([]string{}, i.importing[k:]...), key)
+			return nil, &utils.RuntimeError{
+				Token: path,
+				Err:   errors.New("Import cycle: " + strings.Join(cycle, " -> ") + "."),
+			}
+		}
+	}
+
+	stmts, err := i.loadModule(path, name, file)
+	if err != nil {
+		return nil, err
+	}
+
+	module := newModule(name, file, i.builtins)
+	i.importing = append(i.importing, key)
+	defer func() { i.importing = i.importing[:len(i.importing)-1] }()
+
+	// Module body shows up in stack traces as a call from the import
+	err = i.enterCall(path, callFrame{
+		function: module.String(),
+		callSite: path,
+		file:     i.module.File,
+	})
+	if err != nil {
+		return nil, i.withStackTrace(err)
+	}
+	defer i.callStack.Pop()
+
+	defer i.enterModule(module)()
+	previous := i.Environment
+	i.Environment = module.Globals
+	defer func() { i.Environment = previous }()
+
+	for _, stmt := range stmts {
+		if _, err := i.execute(stmt); err != nil {
+			return nil, i.withStackTrace(err)
+		}
+	}
+
+	i.modules[key] = module
+	return module, nil
+}
+
+// loadModule Reads, parses and resolves the file of the module.
+// Static errors in the file are reported to the collector
+func (i *Interpreter) loadModule(path token.Token, name, file string) ([]parser.Stmt, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &utils.RuntimeError{
+			Token: path,
+			Err:   errors.New("Can't read module '" + name + "'."),
+		}
+	}
+	i.diag.AddSource(file, string(source))
+
+	diag := diagnostics.NewCollector()
+	tokens := scanner.NewScanner(string(source), diag).ScanTokens()
+	stmts, err := parser.NewParser(tokens, diag).Parse()
+	if err == nil && !diag.HasErrors() {
+		resolver.NewResolver(i, diag).Resolve(stmts)
+	}
+
+	for _, d := range diag.Diagnostics() {
+		d.File = file
+		i.diag.Report(d)
+	}
+	if diag.HasErrors() {
+		return nil, &utils.RuntimeError{
+			Token: path,
+			Err:   errors.New("Module '" + name + "' has errors."),
+		}
+	}
+	return stmts, nil
+}
+
+// findModule Looks for the module next to the importing file
+// and then in each directory of the search path
+func (i *Interpreter) findModule(name string) (string, bool) {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = []string{filepath.Join(filepath.Dir(i.module.File), name)}
+		for _, dir := range i.searchPath {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
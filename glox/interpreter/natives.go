@@ -0,0 +1,40 @@
+package interpreter
+
+import (
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// nativeFunction Makes a function of the standard library callable from Lox
+type nativeFunction struct {
+	native *stdlib.NativeFunction
+}
+
+func (n *nativeFunction) Arity() int {
+	return n.native.Arity
+}
+
+func (n *nativeFunction) Call(i *Interpreter, arguments []value.Value) (value.Value, error) {
+	result, err := n.native.Call(i.ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+	// The script may have been stopped while the native ran
+	if err := i.interrupted(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (n *nativeFunction) Type() value.Type {
+	return value.CALLABLE_TYPE
+}
+
+func (n *nativeFunction) String() string {
+	return n.native.String()
+}
+
+// DefineNative Makes the native function available to every module
+func (i *Interpreter) DefineNative(fn *stdlib.NativeFunction) {
+	i.builtins.Define(fn.Name, &nativeFunction{native: fn})
+}
@@ -1,11 +1,15 @@
 package interpreter
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
 
 type ReturnError struct {
-	Value interface{}
+	Value value.Value
 }
 
 func (r *ReturnError) Error() string {
-	return fmt.Sprint("Return error: %s", r.Value)
+	return fmt.Sprintf("Return error: %s", value.ToString(r.Value))
 }
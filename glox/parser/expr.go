@@ -2,19 +2,31 @@ package parser
 
 import (
 	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/value"
 )
 
 type Expr interface {
 	Visit(VisitExpr) (interface{}, error)
+	Span() token.Span
+	SetSpan(token.Span)
 }
 
 type VisitExpr interface {
 	VisitAssignExpr(*Assign) (interface{}, error)
 	VisitBinaryExpr(*Binary) (interface{}, error)
 	VisitCallExpr(*Call) (interface{}, error)
+	VisitGetExpr(*Get) (interface{}, error)
 	VisitGroupingExpr(*Grouping) (interface{}, error)
+	VisitIndexExpr(*Index) (interface{}, error)
+	VisitLambdaExpr(*Lambda) (interface{}, error)
+	VisitListExpr(*List) (interface{}, error)
 	VisitLiteralExpr(*Literal) (interface{}, error)
 	VisitLogicalExpr(*Logical) (interface{}, error)
+	VisitMapExpr(*Map) (interface{}, error)
+	VisitSetExpr(*Set) (interface{}, error)
+	VisitSetIndexExpr(*SetIndex) (interface{}, error)
+	VisitSuperExpr(*Super) (interface{}, error)
+	VisitThisExpr(*This) (interface{}, error)
 	VisitUnaryExpr(*Unary) (interface{}, error)
 	VisitVariableExpr(*Variable) (interface{}, error)
 }
@@ -22,6 +34,8 @@ type VisitExpr interface {
 type Assign struct {
 	Name  token.Token
 	Value Expr
+
+	span token.Span
 }
 
 func NewAssign(name token.Token, value Expr) Expr {
@@ -35,10 +49,20 @@ func (expr *Assign) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitAssignExpr(expr)
 }
 
+func (expr *Assign) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Assign) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Binary struct {
 	Left     Expr
 	Operator token.Token
 	Right    Expr
+
+	span token.Span
 }
 
 func NewBinary(left Expr, operator token.Token, right Expr) Expr {
@@ -53,10 +77,20 @@ func (expr *Binary) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitBinaryExpr(expr)
 }
 
+func (expr *Binary) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Binary) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Call struct {
 	Callee    Expr
 	Paren     token.Token
 	Arguments []Expr
+
+	span token.Span
 }
 
 func NewCall(callee Expr, paren token.Token, arguments []Expr) Expr {
@@ -71,8 +105,44 @@ func (expr *Call) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitCallExpr(expr)
 }
 
+func (expr *Call) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Call) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Get struct {
+	Object Expr
+	Name   token.Token
+
+	span token.Span
+}
+
+func NewGet(object Expr, name token.Token) Expr {
+	return &Get{
+		Object: object,
+		Name:   name,
+	}
+}
+
+func (expr *Get) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitGetExpr(expr)
+}
+
+func (expr *Get) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Get) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Grouping struct {
 	Expression Expr
+
+	span token.Span
 }
 
 func NewGrouping(expression Expr) Expr {
@@ -85,11 +155,99 @@ func (expr *Grouping) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitGroupingExpr(expr)
 }
 
+func (expr *Grouping) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Grouping) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Index struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+
+	span token.Span
+}
+
+func NewIndex(object Expr, bracket token.Token, index Expr) Expr {
+	return &Index{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+	}
+}
+
+func (expr *Index) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitIndexExpr(expr)
+}
+
+func (expr *Index) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Index) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Lambda struct {
+	Function *Function
+
+	span token.Span
+}
+
+func NewLambda(function *Function) Expr {
+	return &Lambda{
+		Function: function,
+	}
+}
+
+func (expr *Lambda) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitLambdaExpr(expr)
+}
+
+func (expr *Lambda) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Lambda) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type List struct {
+	Bracket  token.Token
+	Elements []Expr
+
+	span token.Span
+}
+
+func NewList(bracket token.Token, elements []Expr) Expr {
+	return &List{
+		Bracket:  bracket,
+		Elements: elements,
+	}
+}
+
+func (expr *List) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitListExpr(expr)
+}
+
+func (expr *List) Span() token.Span {
+	return expr.span
+}
+
+func (expr *List) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Literal struct {
-	Value token.Object
+	Value value.Value
+
+	span token.Span
 }
 
-func NewLiteral(value token.Object) Expr {
+func NewLiteral(value value.Value) Expr {
 	return &Literal{
 		Value: value,
 	}
@@ -99,10 +257,20 @@ func (expr *Literal) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitLiteralExpr(expr)
 }
 
+func (expr *Literal) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Literal) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Logical struct {
 	Left     Expr
 	Operator token.Token
 	Right    Expr
+
+	span token.Span
 }
 
 func NewLogical(left Expr, operator token.Token, right Expr) Expr {
@@ -117,9 +285,155 @@ func (expr *Logical) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitLogicalExpr(expr)
 }
 
+func (expr *Logical) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Logical) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Map struct {
+	Brace  token.Token
+	Keys   []Expr
+	Values []Expr
+
+	span token.Span
+}
+
+func NewMap(brace token.Token, keys []Expr, values []Expr) Expr {
+	return &Map{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}
+}
+
+func (expr *Map) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitMapExpr(expr)
+}
+
+func (expr *Map) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Map) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Set struct {
+	Object Expr
+	Name   token.Token
+	Value  Expr
+
+	span token.Span
+}
+
+func NewSet(object Expr, name token.Token, value Expr) Expr {
+	return &Set{
+		Object: object,
+		Name:   name,
+		Value:  value,
+	}
+}
+
+func (expr *Set) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitSetExpr(expr)
+}
+
+func (expr *Set) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Set) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type SetIndex struct {
+	Object  Expr
+	Bracket token.Token
+	Index   Expr
+	Value   Expr
+
+	span token.Span
+}
+
+func NewSetIndex(object Expr, bracket token.Token, index Expr, value Expr) Expr {
+	return &SetIndex{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+		Value:   value,
+	}
+}
+
+func (expr *SetIndex) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitSetIndexExpr(expr)
+}
+
+func (expr *SetIndex) Span() token.Span {
+	return expr.span
+}
+
+func (expr *SetIndex) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Super struct {
+	Keyword token.Token
+	Method  token.Token
+
+	span token.Span
+}
+
+func NewSuper(keyword token.Token, method token.Token) Expr {
+	return &Super{
+		Keyword: keyword,
+		Method:  method,
+	}
+}
+
+func (expr *Super) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitSuperExpr(expr)
+}
+
+func (expr *Super) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Super) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type This struct {
+	Keyword token.Token
+
+	span token.Span
+}
+
+func NewThis(keyword token.Token) Expr {
+	return &This{
+		Keyword: keyword,
+	}
+}
+
+func (expr *This) Visit(visitor VisitExpr) (interface{}, error) {
+	return visitor.VisitThisExpr(expr)
+}
+
+func (expr *This) Span() token.Span {
+	return expr.span
+}
+
+func (expr *This) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Unary struct {
 	Operator token.Token
 	Right    Expr
+
+	span token.Span
 }
 
 func NewUnary(operator token.Token, right Expr) Expr {
@@ -133,8 +447,18 @@ func (expr *Unary) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitUnaryExpr(expr)
 }
 
+func (expr *Unary) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Unary) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Variable struct {
 	Name token.Token
+
+	span token.Span
 }
 
 func NewVariable(name token.Token) Expr {
@@ -146,3 +470,11 @@ func NewVariable(name token.Token) Expr {
 func (expr *Variable) Visit(visitor VisitExpr) (interface{}, error) {
 	return visitor.VisitVariableExpr(expr)
 }
+
+func (expr *Variable) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Variable) SetSpan(span token.Span) {
+	expr.span = span
+}
@@ -1,25 +1,33 @@
 package parser
 
 import (
-	"errors"
-
+	"github.com/madraceee/interpreters/glox/diagnostics"
 	"github.com/madraceee/interpreters/glox/token"
 	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
 )
 
 type Parser struct {
 	Tokens  []token.Token
 	Current int
+	diag    *diagnostics.Collector
+	// Errors found while parsing, also reported to diag
+	errors diagnostics.List
 }
 
-func NewParser(tokens []token.Token) *Parser {
+func NewParser(tokens []token.Token, diag *diagnostics.Collector) *Parser {
 	return &Parser{
 		Tokens:  tokens,
 		Current: 0,
+		diag:    diag,
+		errors:  make(diagnostics.List, 0),
 	}
 }
 
-func (p *Parser) Parse() []Stmt {
+// Parse Parses all the declarations.
+// After an error the parser synchronizes and continues, so the returned
+// error holds every error found
+func (p *Parser) Parse() ([]Stmt, error) {
 	statements := make([]Stmt, 0)
 	for !p.isAtEnd() {
 		stmt, err := p.declaration()
@@ -31,22 +39,81 @@ func (p *Parser) Parse() []Stmt {
 		statements = append(statements, stmt)
 	}
 
-	return statements
+	if len(p.errors) > 0 {
+		return statements, p.errors
+	}
+	return statements, nil
 }
 
 // Functions for stmt.go
 // Add syncrhonize
 func (p *Parser) declaration() (Stmt, error) {
-	if p.match(token.FUN) {
-		return p.function("function")
+	if p.match(token.CLASS) {
+		return p.classDeclaration()
+	}
+	// fun followed by '(' starts an anonymous function expression
+	if p.check(token.FUN) && !p.checkNext(token.LEFT_PARAN) {
+		keyword := p.advance()
+		function, err := p.function("function")
+		if err != nil {
+			return nil, err
+		}
+		return spanned(function, p.spanFrom(keyword)), nil
 	}
 	if p.match(token.VAR) {
 		return p.varDeclaration()
 	}
+	if p.match(token.IMPORT) {
+		return p.importDeclaration()
+	}
 
 	return p.statement()
 }
+func (p *Parser) classDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	name, err := p.consume(token.IDENTIFIER, "Expect class name.")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *Variable
+	if p.match(token.LESS) {
+		superclassName, err := p.consume(token.IDENTIFIER, "Expect superclass name.")
+		if err != nil {
+			return nil, err
+		}
+		superclass = spanned(NewVariable(*superclassName), superclassName.Span()).(*Variable)
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before class body.")
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]*Function, 0)
+	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+	}
+
+	_, err = p.consume(token.RIGHT_BRACE, "Expect '}' after class body.")
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewClass(*name, superclass, methods), p.spanFrom(keyword)), nil
+}
+
 func (p *Parser) statement() (Stmt, error) {
+	if p.match(token.BREAK) {
+		return p.breakStatement()
+	}
+	if p.match(token.CONTINUE) {
+		return p.continueStatement()
+	}
 	if p.match(token.FOR) {
 		return p.forStatement()
 	}
@@ -59,12 +126,19 @@ func (p *Parser) statement() (Stmt, error) {
 	if p.match(token.RETURN) {
 		return p.returnStatement()
 	}
+	if p.match(token.THROW) {
+		return p.throwStatement()
+	}
+	if p.match(token.TRY) {
+		return p.tryStatement()
+	}
 	if p.match(token.WHILE) {
 		return p.whileStatemet()
 	}
 	if p.match(token.LEFT_BRACE) {
+		brace := p.previous()
 		stmt, err := p.block()
-		return NewBlock(stmt), err
+		return spanned(NewBlock(stmt), p.spanFrom(brace)), err
 	}
 
 	return p.expressionStatement()
@@ -75,25 +149,50 @@ func (p *Parser) block() ([]Stmt, error) {
 	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
 		stmts, err := p.declaration()
 		if err != nil {
-			return nil, err
+			// Recover inside the block to find the errors after this one
+			p.synchronizeInBlock()
+			continue
 		}
 		statements = append(statements, stmts)
 	}
 
-	p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
+	_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after block.")
+	if err != nil {
+		return nil, err
+	}
 	return statements, nil
 }
 
 func (p *Parser) printStatement() (Stmt, error) {
+	keyword := p.previous()
 	expr, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	p.consume(token.SEMICOLON, "Expect ';' after value")
-	return &Print{
-		Expression: expr,
-	}, nil
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after value")
+	if err != nil {
+		return nil, err
+	}
+	return spanned(NewPrint(expr), p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) breakStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(token.SEMICOLON, "Expect ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+	return spanned(NewBreak(*keyword), p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) continueStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(token.SEMICOLON, "Expect ';' after 'continue'.")
+	if err != nil {
+		return nil, err
+	}
+	return spanned(NewContinue(*keyword), p.spanFrom(keyword)), nil
 }
 
 func (p *Parser) returnStatement() (Stmt, error) {
@@ -112,15 +211,93 @@ func (p *Parser) returnStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	return NewReturn(*keyword, value), nil
+	return spanned(NewReturn(*keyword, value), p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) throwStatement() (Stmt, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after thrown value.")
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewThrow(*keyword, value), p.spanFrom(keyword)), nil
+}
+
+// tryStatement Parses a try block followed by a catch clause, a finally clause or both.
+// A clause which is missing has a nil body
+func (p *Parser) tryStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(token.LEFT_BRACE, "Expect '{' after 'try'.")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	var catchName token.Token
+	var catchBody []Stmt
+	if p.match(token.CATCH) {
+		_, err = p.consume(token.LEFT_PARAN, "Expect '(' after 'catch'.")
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.consume(token.IDENTIFIER, "Expect error variable name.")
+		if err != nil {
+			return nil, err
+		}
+		catchName = *name
+		_, err = p.consume(token.RIGHT_PARAN, "Expect ')' after error variable.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(token.LEFT_BRACE, "Expect '{' before catch body.")
+		if err != nil {
+			return nil, err
+		}
+		catchBody, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finallyBody []Stmt
+	if p.match(token.FINALLY) {
+		_, err = p.consume(token.LEFT_BRACE, "Expect '{' after 'finally'.")
+		if err != nil {
+			return nil, err
+		}
+		finallyBody, err = p.block()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catchBody == nil && finallyBody == nil {
+		return nil, p.error(p.peek(), "Expect 'catch' or 'finally' after try block.")
+	}
+
+	return spanned(NewTry(*keyword, body, catchName, catchBody, finallyBody), p.spanFrom(keyword)), nil
 }
 
 func (p *Parser) forStatement() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(token.LEFT_PARAN, "Expect '(' after for.")
 	if err != nil {
 		return nil, err
 	}
 
+	if p.check(token.IDENTIFIER) && p.checkNext(token.IN) {
+		return p.forInStatement(keyword)
+	}
+
 	// Declaration part
 	var initializer Stmt
 	if p.match(token.SEMICOLON) {
@@ -173,29 +350,46 @@ func (p *Parser) forStatement() (Stmt, error) {
 
 	// Scoping into New Expr
 	// Desugaring for syntax to be used with existing functions
-	if increment != nil {
-		body = NewBlock([]Stmt{body, NewExpression(increment)})
-	}
-
+	// Desugared nodes point to the whole for statement.
+	// The increment is kept on the loop so it still runs after a continue
+	span := p.spanFrom(keyword)
 	if condition == nil {
-		condition = NewLiteral(token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: true,
-		})
-	}
-	body = &While{
-		Condition: condition,
-		Body:      body,
+		condition = spanned(NewLiteral(value.Bool(true)), keyword.Span())
 	}
+	body = spanned(NewWhile(condition, body, increment), span)
 
 	if initializer != nil {
-		body = NewBlock([]Stmt{initializer, body})
+		body = spanned(NewBlock([]Stmt{initializer, body}), span)
 	}
 
 	return body, nil
 }
 
+// forInStatement Parses the rest of a `for (name in iterable)` loop
+func (p *Parser) forInStatement(keyword *token.Token) (Stmt, error) {
+	name := p.advance()
+	in := p.advance()
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.RIGHT_PARAN, "Expect ')' after iterable.")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewForIn(*name, *in, iterable, body), p.spanFrom(keyword)), nil
+}
+
 func (p *Parser) whileStatemet() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(token.LEFT_PARAN, "Expect '(' after while.")
 	if err != nil {
 		return nil, err
@@ -216,7 +410,7 @@ func (p *Parser) whileStatemet() (Stmt, error) {
 		return nil, err
 	}
 
-	return NewWhile(condition, body), nil
+	return spanned(NewWhile(condition, body, nil), p.spanFrom(keyword)), nil
 }
 
 func (p *Parser) expressionStatement() (Stmt, error) {
@@ -225,13 +419,15 @@ func (p *Parser) expressionStatement() (Stmt, error) {
 		return nil, err
 	}
 
-	p.consume(token.SEMICOLON, "Expect ';' after value")
-	return &Expression{
-		Expression: expr,
-	}, nil
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after value")
+	if err != nil {
+		return nil, err
+	}
+	return spanned(NewExpression(expr), token.Join(expr.Span(), p.previous().Span())), nil
 }
 
 func (p *Parser) varDeclaration() (Stmt, error) {
+	keyword := p.previous()
 	name, err := p.consume(token.IDENTIFIER, "Expecting variable name")
 	if err != nil {
 		return nil, err
@@ -250,13 +446,37 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 		return nil, err
 	}
 
-	return &Var{
-		Name:        *name,
-		Initializer: initializer,
-	}, nil
+	return spanned(NewVar(*name, initializer), p.spanFrom(keyword)), nil
+}
+
+// importDeclaration Parses `import "path" as name;`
+func (p *Parser) importDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	path, err := p.consume(token.STRING, "Expect module path after 'import'.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.AS, "Expect 'as' after module path.")
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := p.consume(token.IDENTIFIER, "Expect module name after 'as'.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.SEMICOLON, "Expect ';' after import.")
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewImport(*keyword, *path, *name), p.spanFrom(keyword)), nil
 }
 
 func (p *Parser) ifStatement() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(token.LEFT_PARAN, "Expect '(' after if.")
 	if err != nil {
 		return nil, err
@@ -283,10 +503,10 @@ func (p *Parser) ifStatement() (Stmt, error) {
 			return nil, err
 		}
 
-		return NewIf(expr, thenBranch, elseBranch), nil
+		return spanned(NewIf(expr, thenBranch, elseBranch), p.spanFrom(keyword)), nil
 	}
 
-	return NewIf(expr, thenBranch, nil), nil
+	return spanned(NewIf(expr, thenBranch, nil), p.spanFrom(keyword)), nil
 }
 
 func (p *Parser) function(kind string) (*Function, error) {
@@ -300,11 +520,37 @@ func (p *Parser) function(kind string) (*Function, error) {
 		return nil, err
 	}
 
+	return p.functionBody(name, kind)
+}
+
+// functionBody Parses the parameters and body of a function after the '('.
+// Anonymous functions are named by their 'fun' keyword
+func (p *Parser) functionBody(name *token.Token, kind string) (*Function, error) {
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	if err != nil {
+		return nil, err
+	}
+
+	stmts, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewFunction(*name, parameters, stmts), p.spanFrom(name)).(*Function), err
+}
+
+// parameters Parses the parameter names up to and including the ')'
+func (p *Parser) parameters() ([]token.Token, error) {
 	parameters := make([]token.Token, 0)
 	if !p.check(token.RIGHT_PARAN) {
 		for {
 			if len(parameters) > 255 {
-				_ = ParserError(p.peek(), "Cannot have more than 255 parameters")
+				_ = p.error(p.peek(), "Cannot have more than 255 parameters")
 			}
 
 			param, err := p.consume(token.IDENTIFIER, "Expect parameter name.")
@@ -319,23 +565,58 @@ func (p *Parser) function(kind string) (*Function, error) {
 		}
 	}
 
-	_, err = p.consume(token.RIGHT_PARAN, "Expect ')' after parameters.")
+	_, err := p.consume(token.RIGHT_PARAN, "Expect ')' after parameters.")
 	if err != nil {
 		return nil, err
 	}
+	return parameters, nil
+}
 
-	_, err = p.consume(token.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+// lambda Parses an anonymous function after the 'fun'
+func (p *Parser) lambda() (Expr, error) {
+	keyword := p.previous()
+	_, err := p.consume(token.LEFT_PARAN, "Expect '(' after 'fun'.")
 	if err != nil {
 		return nil, err
 	}
 
-	stmts, err := p.block()
+	function, err := p.functionBody(keyword, "function")
 	if err != nil {
 		return nil, err
 	}
+	return spanned(NewLambda(function), function.Span()), nil
+}
 
-	return NewFunction(*name, parameters, stmts).(*Function), err
+// isArrow Looks ahead from the '(' for `(a, b) =>` without consuming anything
+func (p *Parser) isArrow() bool {
+	k := p.Current + 1
+	if k < len(p.Tokens) && p.Tokens[k].TokenType == token.IDENTIFIER {
+		k++
+		for k+1 < len(p.Tokens) && p.Tokens[k].TokenType == token.COMMA && p.Tokens[k+1].TokenType == token.IDENTIFIER {
+			k += 2
+		}
+	}
+	return k+1 < len(p.Tokens) && p.Tokens[k].TokenType == token.RIGHT_PARAN && p.Tokens[k+1].TokenType == token.ARROW
+}
+
+// arrow Parses `(a, b) => expression` into a function returning the expression.
+// The body is a single expression so that `=> {` is not mistaken for a map
+func (p *Parser) arrow() (Expr, error) {
+	paren := p.advance()
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	arrow := p.advance()
+
+	body, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
 
+	ret := spanned(NewReturn(*arrow, body), body.Span())
+	function := spanned(NewFunction(*paren, parameters, []Stmt{ret}), p.spanFrom(paren)).(*Function)
+	return spanned(NewLambda(function), function.Span()), nil
 }
 
 // Functions for expr.go
@@ -356,13 +637,20 @@ func (p *Parser) assignment() (Expr, error) {
 			return nil, err
 		}
 
+		span := token.Join(expr.Span(), value.Span())
 		switch expr.(type) {
 		case *Variable:
 			name := expr.(*Variable).Name
-			return NewAssign(name, value), nil
+			return spanned(NewAssign(name, value), span), nil
+		case *Get:
+			get := expr.(*Get)
+			return spanned(NewSet(get.Object, get.Name, value), span), nil
+		case *Index:
+			index := expr.(*Index)
+			return spanned(NewSetIndex(index.Object, index.Bracket, index.Index, value), span), nil
 		}
 
-		return nil, ParserError(equals, "Invalid assignment target")
+		return nil, p.error(equals, "Invalid assignment target")
 
 	}
 
@@ -382,7 +670,7 @@ func (p *Parser) or() (Expr, error) {
 			return nil, err
 		}
 
-		expr = NewLogical(expr, *operator, right)
+		expr = spanned(NewLogical(expr, *operator, right), token.Join(expr.Span(), right.Span()))
 	}
 
 	return expr, nil
@@ -400,7 +688,7 @@ func (p *Parser) and() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		expr = NewLogical(expr, *operator, right)
+		expr = spanned(NewLogical(expr, *operator, right), token.Join(expr.Span(), right.Span()))
 	}
 
 	return expr, nil
@@ -418,7 +706,7 @@ func (p *Parser) equality() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		expr = NewBinary(expr, *operator, right)
+		expr = spanned(NewBinary(expr, *operator, right), token.Join(expr.Span(), right.Span()))
 	}
 
 	return expr, nil
@@ -436,7 +724,7 @@ func (p *Parser) comparison() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		expr = NewBinary(expr, *operator, right)
+		expr = spanned(NewBinary(expr, *operator, right), token.Join(expr.Span(), right.Span()))
 	}
 
 	return expr, nil
@@ -454,7 +742,7 @@ func (p *Parser) term() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		expr = NewBinary(expr, *operator, right)
+		expr = spanned(NewBinary(expr, *operator, right), token.Join(expr.Span(), right.Span()))
 	}
 
 	return expr, nil
@@ -472,7 +760,7 @@ func (p *Parser) factor() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		expr = NewBinary(expr, *operator, right)
+		expr = spanned(NewBinary(expr, *operator, right), token.Join(expr.Span(), right.Span()))
 	}
 
 	return expr, nil
@@ -482,7 +770,10 @@ func (p *Parser) unary() (Expr, error) {
 	if p.match(token.BANG, token.MINUS) {
 		operator := p.previous()
 		right, err := p.unary()
-		return NewUnary(*operator, right), err
+		if err != nil {
+			return nil, err
+		}
+		return spanned(NewUnary(*operator, right), token.Join(operator.Span(), right.Span())), nil
 	}
 
 	return p.call()
@@ -500,6 +791,23 @@ func (p *Parser) call() (Expr, error) {
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(token.DOT) {
+			name, err := p.consume(token.IDENTIFIER, "Expect property name after '.'.")
+			if err != nil {
+				return nil, err
+			}
+			expr = spanned(NewGet(expr, *name), token.Join(expr.Span(), name.Span()))
+		} else if p.match(token.LEFT_BRACKET) {
+			bracket := p.previous()
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			closing, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+			expr = spanned(NewIndex(expr, *bracket, index), token.Join(expr.Span(), closing.Span()))
 		} else {
 			break
 		}
@@ -519,7 +827,7 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 			}
 
 			if len(arguments) > 255 {
-				ParserError(p.peek(), "Cannot have more than 255 arguments.")
+				_ = p.error(p.peek(), "Cannot have more than 255 arguments.")
 			}
 
 			arguments = append(arguments, exp)
@@ -534,42 +842,131 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 		return nil, err
 	}
 
-	return NewCall(callee, *paren, arguments), nil
+	return spanned(NewCall(callee, *paren, arguments), token.Join(callee.Span(), paren.Span())), nil
+}
+
+// list Parses the elements of a list literal after the '['
+func (p *Parser) list() (Expr, error) {
+	bracket := p.previous()
+	elements := make([]Expr, 0)
+
+	if !p.check(token.RIGHT_BRACKET) {
+		for {
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, element)
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewList(*bracket, elements), p.spanFrom(bracket)), nil
+}
+
+// mapLiteral Parses the entries of a map literal after the '{'
+func (p *Parser) mapLiteral() (Expr, error) {
+	brace := p.previous()
+	keys := make([]Expr, 0)
+	values := make([]Expr, 0)
+
+	if !p.check(token.RIGHT_BRACE) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = p.consume(token.COLON, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, key)
+			values = append(values, val)
+			if !p.match(token.COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(token.RIGHT_BRACE, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return spanned(NewMap(*brace, keys, values), p.spanFrom(brace)), nil
 }
 
 func (p *Parser) primary() (Expr, error) {
 	if p.match(token.FALSE) {
-		return NewLiteral(token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: false,
-		}), nil
+		return spanned(NewLiteral(value.Bool(false)), p.previous().Span()), nil
 	}
 	if p.match(token.TRUE) {
-		return NewLiteral(token.Object{
-			ObjType:    token.BOOL_TYPE,
-			Value_bool: true,
-		}), nil
+		return spanned(NewLiteral(value.Bool(true)), p.previous().Span()), nil
 	}
 	if p.match(token.NIL) {
-		return NewLiteral(token.Object{
-			ObjType:   token.STRING_TYPE,
-			Value_str: "nil",
-		}), nil
+		return spanned(NewLiteral(value.Nil), p.previous().Span()), nil
 	}
 
 	if p.match(token.NUMBER, token.STRING) {
-		return NewLiteral(token.Object{
-			ObjType:     p.previous().Literal.ObjType,
-			Value_str:   p.previous().Literal.Value_str,
-			Value_float: p.previous().Literal.Value_float,
-		}), nil
+		return spanned(NewLiteral(p.previous().Literal), p.previous().Span()), nil
+	}
+
+	if p.match(token.SUPER) {
+		keyword := p.previous()
+		_, err := p.consume(token.DOT, "Expect '.' after 'super'.")
+		if err != nil {
+			return nil, err
+		}
+		method, err := p.consume(token.IDENTIFIER, "Expect superclass method name.")
+		if err != nil {
+			return nil, err
+		}
+		return spanned(NewSuper(*keyword, *method), p.spanFrom(keyword)), nil
+	}
+
+	if p.match(token.THIS) {
+		return spanned(NewThis(*p.previous()), p.previous().Span()), nil
+	}
+
+	if p.match(token.FUN) {
+		return p.lambda()
+	}
+
+	if p.check(token.LEFT_PARAN) && p.isArrow() {
+		return p.arrow()
 	}
 
 	if p.match(token.IDENTIFIER) {
-		return NewVariable(*p.previous()), nil
+		return spanned(NewVariable(*p.previous()), p.previous().Span()), nil
+	}
+
+	if p.match(token.LEFT_BRACKET) {
+		return p.list()
+	}
+
+	// Statements starting with '{' are parsed as blocks before
+	// reaching here, so a brace in an expression is always a map
+	if p.match(token.LEFT_BRACE) {
+		return p.mapLiteral()
 	}
 
 	if p.match(token.LEFT_PARAN) {
+		paren := p.previous()
 		expr, err := p.expression()
 		if err != nil {
 			return nil, err
@@ -579,10 +976,10 @@ func (p *Parser) primary() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		return NewGrouping(expr), nil
+		return spanned(NewGrouping(expr), p.spanFrom(paren)), nil
 	}
 
-	return nil, ParserError(p.peek(), "Expect expression.")
+	return nil, p.error(p.peek(), "Expect expression.")
 }
 
 func (p *Parser) consume(_type token.TokenType, message string) (*token.Token, error) {
@@ -590,7 +987,7 @@ func (p *Parser) consume(_type token.TokenType, message string) (*token.Token, e
 		return p.advance(), nil
 	}
 
-	return nil, ParserError(p.peek(), message)
+	return nil, p.error(p.peek(), message)
 }
 
 func (p *Parser) match(types ...token.TokenType) bool {
@@ -611,6 +1008,15 @@ func (p *Parser) check(_type token.TokenType) bool {
 	return p.peek().TokenType == _type
 }
 
+// checkNext Checks the type of the token after the current one
+func (p *Parser) checkNext(_type token.TokenType) bool {
+	if p.isAtEnd() || p.Current+1 >= len(p.Tokens) {
+		return false
+	}
+
+	return p.Tokens[p.Current+1].TokenType == _type
+}
+
 func (p *Parser) advance() *token.Token {
 	if !p.isAtEnd() {
 		p.Current++
@@ -634,20 +1040,48 @@ func (p *Parser) Synchronize() {
 	p.advance()
 
 	for !p.isAtEnd() {
-		if p.previous().TokenType == token.SEMICOLON {
+		if p.previous().TokenType == token.SEMICOLON || startsStatement(p.peek().TokenType) {
 			return
 		}
 
-		switch p.peek().TokenType {
-		case token.CLASS, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN:
+		p.advance()
+	}
+}
+
+// synchronizeInBlock Is Synchronize which stops before a '}', so the
+// block the error is in still ends at its closing brace
+func (p *Parser) synchronizeInBlock() {
+	for !p.isAtEnd() && !p.check(token.RIGHT_BRACE) {
+		p.advance()
+		if p.previous().TokenType == token.SEMICOLON || startsStatement(p.peek().TokenType) {
 			return
 		}
+	}
+}
 
-		p.advance()
+// startsStatement Returns true for the keywords the parser synchronizes at
+func startsStatement(tokenType token.TokenType) bool {
+	switch tokenType {
+	case token.CLASS, token.FUN, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT, token.RETURN, token.IMPORT, token.THROW, token.TRY:
+		return true
 	}
+	return false
+}
+
+// spanFrom Returns the span from the start token to the last consumed token
+func (p *Parser) spanFrom(start *token.Token) token.Span {
+	return token.Join(start.Span(), p.previous().Span())
+}
+
+// spanned Sets the span of the node and returns it
+func spanned[T interface{ SetSpan(token.Span) }](node T, span token.Span) T {
+	node.SetSpan(span)
+	return node
 }
 
-func ParserError(token *token.Token, message string) error {
-	utils.TError(*token, message)
-	return errors.New("ParserError: error while parsing")
+// error Reports the error at the token and returns it
+func (p *Parser) error(tok *token.Token, message string) error {
+	d := p.diag.Error(diagnostics.PARSE_ERROR, tok.Span(), message)
+	p.errors = append(p.errors, d)
+	return d
 }
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/scanner"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			"missing ';' before '}'",
+			"fun f() {\n print 1\n}\nprint 2;",
+			[]string{"[Line 3] Error: Expect ';' after value"},
+		},
+		{
+			"errors on both sides of a block",
+			"var = 1;\n{\n print;\n}\nvar = 2;",
+			[]string{
+				"[Line 1] Error: Expecting variable name",
+				"[Line 3] Error: Expect expression.",
+				"[Line 5] Error: Expecting variable name",
+			},
+		},
+		{
+			"two errors in one block",
+			"{\n print;\n var = 1;\n}",
+			[]string{
+				"[Line 2] Error: Expect expression.",
+				"[Line 3] Error: Expecting variable name",
+			},
+		},
+		{
+			"unclosed block",
+			"{\n print 1;",
+			[]string{"[Line 2] Error: Expect '}' after block."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diag := diagnostics.NewCollector()
+			NewParser(scanner.NewScanner(tt.source, diag).ScanTokens(), diag).Parse()
+
+			got := make([]string, 0)
+			for _, d := range diag.Diagnostics() {
+				got = append(got, d.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diagnostics = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,21 +6,32 @@ import (
 
 type Stmt interface {
 	Visit(VisitStmt) (interface{}, error)
+	Span() token.Span
+	SetSpan(token.Span)
 }
 
 type VisitStmt interface {
 	VisitBlockStmt(*Block) (interface{}, error)
+	VisitBreakStmt(*Break) (interface{}, error)
+	VisitClassStmt(*Class) (interface{}, error)
+	VisitContinueStmt(*Continue) (interface{}, error)
 	VisitExpressionStmt(*Expression) (interface{}, error)
+	VisitForInStmt(*ForIn) (interface{}, error)
 	VisitFunctionStmt(*Function) (interface{}, error)
 	VisitIfStmt(*If) (interface{}, error)
+	VisitImportStmt(*Import) (interface{}, error)
 	VisitPrintStmt(*Print) (interface{}, error)
 	VisitReturnStmt(*Return) (interface{}, error)
+	VisitThrowStmt(*Throw) (interface{}, error)
+	VisitTryStmt(*Try) (interface{}, error)
 	VisitVarStmt(*Var) (interface{}, error)
 	VisitWhileStmt(*While) (interface{}, error)
 }
 
 type Block struct {
 	Statements []Stmt
+
+	span token.Span
 }
 
 func NewBlock(statements []Stmt) Stmt {
@@ -33,8 +44,94 @@ func (expr *Block) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitBlockStmt(expr)
 }
 
+func (expr *Block) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Block) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Break struct {
+	Keyword token.Token
+
+	span token.Span
+}
+
+func NewBreak(keyword token.Token) Stmt {
+	return &Break{
+		Keyword: keyword,
+	}
+}
+
+func (expr *Break) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitBreakStmt(expr)
+}
+
+func (expr *Break) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Break) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Class struct {
+	Name       token.Token
+	Superclass *Variable
+	Methods    []*Function
+
+	span token.Span
+}
+
+func NewClass(name token.Token, superclass *Variable, methods []*Function) Stmt {
+	return &Class{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+}
+
+func (expr *Class) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitClassStmt(expr)
+}
+
+func (expr *Class) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Class) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Continue struct {
+	Keyword token.Token
+
+	span token.Span
+}
+
+func NewContinue(keyword token.Token) Stmt {
+	return &Continue{
+		Keyword: keyword,
+	}
+}
+
+func (expr *Continue) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitContinueStmt(expr)
+}
+
+func (expr *Continue) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Continue) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Expression struct {
 	Expression Expr
+
+	span token.Span
 }
 
 func NewExpression(expression Expr) Stmt {
@@ -47,10 +144,50 @@ func (expr *Expression) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitExpressionStmt(expr)
 }
 
+func (expr *Expression) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Expression) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type ForIn struct {
+	Name     token.Token
+	In       token.Token
+	Iterable Expr
+	Body     Stmt
+
+	span token.Span
+}
+
+func NewForIn(name token.Token, in token.Token, iterable Expr, body Stmt) Stmt {
+	return &ForIn{
+		Name:     name,
+		In:       in,
+		Iterable: iterable,
+		Body:     body,
+	}
+}
+
+func (expr *ForIn) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitForInStmt(expr)
+}
+
+func (expr *ForIn) Span() token.Span {
+	return expr.span
+}
+
+func (expr *ForIn) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Function struct {
 	Name   token.Token
 	Params []token.Token
 	Body   []Stmt
+
+	span token.Span
 }
 
 func NewFunction(name token.Token, params []token.Token, body []Stmt) Stmt {
@@ -65,10 +202,20 @@ func (expr *Function) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitFunctionStmt(expr)
 }
 
+func (expr *Function) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Function) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type If struct {
 	Condition  Expr
 	ThenBranch Stmt
 	ElseBranch Stmt
+
+	span token.Span
 }
 
 func NewIf(condition Expr, thenBranch Stmt, elseBranch Stmt) Stmt {
@@ -83,8 +230,46 @@ func (expr *If) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitIfStmt(expr)
 }
 
+func (expr *If) Span() token.Span {
+	return expr.span
+}
+
+func (expr *If) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Import struct {
+	Keyword token.Token
+	Path    token.Token
+	Name    token.Token
+
+	span token.Span
+}
+
+func NewImport(keyword token.Token, path token.Token, name token.Token) Stmt {
+	return &Import{
+		Keyword: keyword,
+		Path:    path,
+		Name:    name,
+	}
+}
+
+func (expr *Import) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitImportStmt(expr)
+}
+
+func (expr *Import) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Import) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Print struct {
 	Expression Expr
+
+	span token.Span
 }
 
 func NewPrint(expression Expr) Stmt {
@@ -97,9 +282,19 @@ func (expr *Print) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitPrintStmt(expr)
 }
 
+func (expr *Print) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Print) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Return struct {
 	Keyword token.Token
 	Value   Expr
+
+	span token.Span
 }
 
 func NewReturn(keyword token.Token, value Expr) Stmt {
@@ -113,9 +308,77 @@ func (expr *Return) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitReturnStmt(expr)
 }
 
+func (expr *Return) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Return) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Throw struct {
+	Keyword token.Token
+	Value   Expr
+
+	span token.Span
+}
+
+func NewThrow(keyword token.Token, value Expr) Stmt {
+	return &Throw{
+		Keyword: keyword,
+		Value:   value,
+	}
+}
+
+func (expr *Throw) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitThrowStmt(expr)
+}
+
+func (expr *Throw) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Throw) SetSpan(span token.Span) {
+	expr.span = span
+}
+
+type Try struct {
+	Keyword     token.Token
+	Body        []Stmt
+	CatchName   token.Token
+	CatchBody   []Stmt
+	FinallyBody []Stmt
+
+	span token.Span
+}
+
+func NewTry(keyword token.Token, body []Stmt, catchName token.Token, catchBody []Stmt, finallyBody []Stmt) Stmt {
+	return &Try{
+		Keyword:     keyword,
+		Body:        body,
+		CatchName:   catchName,
+		CatchBody:   catchBody,
+		FinallyBody: finallyBody,
+	}
+}
+
+func (expr *Try) Visit(visitor VisitStmt) (interface{}, error) {
+	return visitor.VisitTryStmt(expr)
+}
+
+func (expr *Try) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Try) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type Var struct {
 	Name        token.Token
 	Initializer Expr
+
+	span token.Span
 }
 
 func NewVar(name token.Token, initializer Expr) Stmt {
@@ -129,18 +392,38 @@ func (expr *Var) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitVarStmt(expr)
 }
 
+func (expr *Var) Span() token.Span {
+	return expr.span
+}
+
+func (expr *Var) SetSpan(span token.Span) {
+	expr.span = span
+}
+
 type While struct {
 	Condition Expr
 	Body      Stmt
+	Increment Expr
+
+	span token.Span
 }
 
-func NewWhile(condition Expr, body Stmt) Stmt {
+func NewWhile(condition Expr, body Stmt, increment Expr) Stmt {
 	return &While{
 		Condition: condition,
 		Body:      body,
+		Increment: increment,
 	}
 }
 
 func (expr *While) Visit(visitor VisitStmt) (interface{}, error) {
 	return visitor.VisitWhileStmt(expr)
 }
+
+func (expr *While) Span() token.Span {
+	return expr.span
+}
+
+func (expr *While) SetSpan(span token.Span) {
+	expr.span = span
+}
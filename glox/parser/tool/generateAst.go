@@ -4,17 +4,18 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 // Types from other packages must be added here
 // Format is of
 // Type : package
-// Importing of package must be done manually
+// Imports are generated for the packages used by the rules
 var (
 	otherPackageTypes map[string]string = map[string]string{
-		"Token":  "token",
-		"Object": "token",
+		"Token": "token",
+		"Value": "value",
 	}
 )
 
@@ -45,13 +46,20 @@ func generateStmt() {
 	defer file.Close()
 	defineAst(file, "Stmt", []string{
 		"Block : []Stmt statements",
+		"Break : Token keyword",
+		"Class : Token name, *Variable superclass, []*Function methods",
+		"Continue : Token keyword",
 		"Expression : Expr expression",
+		"ForIn : Token name, Token in, Expr iterable, Stmt body",
 		"Function : Token name, []Token params, []Stmt body",
 		"If : Expr condition, Stmt thenBranch, Stmt elseBranch",
+		"Import : Token keyword, Token path, Token name",
 		"Print : Expr expression",
 		"Return : Token keyword, Expr value",
+		"Throw : Token keyword, Expr value",
+		"Try : Token keyword, []Stmt body, Token catchName, []Stmt catchBody, []Stmt finallyBody",
 		"Var : Token name, Expr initializer",
-		"While : Expr condition, Stmt body",
+		"While : Expr condition, Stmt body, Expr increment",
 	})
 }
 
@@ -79,9 +87,18 @@ func generateExpr() {
 		"Assign : Token name, Expr value",
 		"Binary : Expr left, Token operator, Expr right",
 		"Call : Expr callee, Token paren, []Expr arguments",
+		"Get : Expr object, Token name",
 		"Grouping : Expr expression",
-		"Literal : Object value",
+		"Index : Expr object, Token bracket, Expr index",
+		"Lambda : *Function function",
+		"List : Token bracket, []Expr elements",
+		"Literal : Value value",
 		"Logical: Expr left, Token operator, Expr right",
+		"Map : Token brace, []Expr keys, []Expr values",
+		"Set : Expr object, Token name, Expr value",
+		"SetIndex : Expr object, Token bracket, Expr index, Expr value",
+		"Super : Token keyword, Token method",
+		"This : Token keyword",
 		"Unary : Token operator, Expr right",
 		"Variable : Token name",
 	})
@@ -89,19 +106,22 @@ func generateExpr() {
 
 func defineAst(file *os.File, basename string, types []string) {
 	builder := strings.Builder{}
-	_, err := builder.WriteString(`
-package parser
-import (
-	"github.com/madraceee/interpreters/glox/token"
-)`)
+	_, err := builder.WriteString("\npackage parser\nimport (\n")
 	if err != nil {
 		log.Fatal(err)
 	}
+	for _, packageName := range usedPackages(types) {
+		builder.WriteString("\t\"github.com/madraceee/interpreters/glox/" + packageName + "\"\n")
+	}
+	builder.WriteString(")")
 
 	// Add interface for all rules to implement
 	// Allows all rules to return a  string of what they hold
+	// and the part of the source they were parsed from
 	builder.WriteString("\ntype  " + basename + " interface{\n")
-	builder.WriteString("\tVisit(Visit" + basename + ") (interface{}, error)\n}\n\n")
+	builder.WriteString("\tVisit(Visit" + basename + ") (interface{}, error)\n")
+	builder.WriteString("\tSpan() token.Span\n")
+	builder.WriteString("\tSetSpan(token.Span)\n}\n\n")
 
 	_, err = file.WriteString(builder.String())
 	if err != nil {
@@ -139,6 +159,7 @@ func defineTypes(file *os.File, basename, classname, fieldlist string) {
 	for _, field := range fieldsWithType {
 		builder.WriteString(toUpperFirstChar(field) + "\n")
 	}
+	builder.WriteString("\nspan token.Span\n")
 	_, err = builder.WriteString("\n}\n")
 	if err != nil {
 		log.Fatal(err)
@@ -166,6 +187,7 @@ func defineTypes(file *os.File, basename, classname, fieldlist string) {
 	}
 
 	defineVisitFunc(file, basename, classname)
+	defineSpanFuncs(file, classname)
 }
 
 func defineVisitFunc(file *os.File, basename, classname string) {
@@ -180,6 +202,21 @@ func defineVisitFunc(file *os.File, basename, classname string) {
 	}
 }
 
+func defineSpanFuncs(file *os.File, classname string) {
+	builder := strings.Builder{}
+	builder.WriteString("\nfunc (expr *" + classname + ") Span() token.Span {\n")
+	builder.WriteString("\treturn expr.span\n")
+	builder.WriteString("}\n")
+	builder.WriteString("\nfunc (expr *" + classname + ") SetSpan(span token.Span) {\n")
+	builder.WriteString("\texpr.span = span\n")
+	builder.WriteString("}\n")
+
+	_, err := file.WriteString(builder.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func defineToBeImplementedInterface(file *os.File, basename string, types []string) {
 	builder := strings.Builder{}
 	builder.WriteString("\ntype  Visit" + basename + " interface{\n")
@@ -196,6 +233,27 @@ func defineToBeImplementedInterface(file *os.File, basename string, types []stri
 	}
 }
 
+// usedPackages Returns the packages of other package types used in the rules.
+// token is always used for the span of the rules
+func usedPackages(types []string) []string {
+	packages := []string{"token"}
+	seen := map[string]bool{"token": true}
+	for _, _type := range types {
+		split := strings.Split(_type, ":")
+		for _, field := range strings.Split(split[1], ",") {
+			vals := strings.Split(strings.Trim(field, " "), " ")
+			fieldType := strings.TrimLeft(vals[0], "[]*")
+			if packageName, ok := otherPackageTypes[fieldType]; ok && !seen[packageName] {
+				seen[packageName] = true
+				packages = append(packages, packageName)
+			}
+		}
+	}
+
+	sort.Strings(packages)
+	return packages
+}
+
 func replaceOtherPackageTypes(otherPackageType string) string {
 	isArr := false
 	if otherPackageType[0:2] == "[]" {
@@ -0,0 +1,442 @@
+package resolver
+
+import (
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+)
+
+// Locals Is implemented by the interpreter to store the scope depth
+// and the slot of every local variable reference
+type Locals interface {
+	Resolve(expr parser.Expr, depth, slot int)
+}
+
+type FunctionType int
+
+const (
+	NONE FunctionType = iota
+	FUNCTION
+	METHOD
+	INITIALIZER
+)
+
+type ClassType int
+
+const (
+	NO_CLASS ClassType = iota
+	CLASS
+	SUBCLASS
+)
+
+// variable A variable declared in a scope. Slots are given in the
+// order the variables are declared, which is the order they are defined
+// in at runtime
+type variable struct {
+	slot int
+	// Whether its initializer has finished
+	ready bool
+}
+
+// scope Maps the names declared in a block, function or class body to the variables
+type scope map[string]*variable
+
+// Resolver Walks the AST once before it is interpreted and
+// binds every variable reference to the scope it was declared in
+type Resolver struct {
+	locals          Locals
+	scopes          utils.Stack[scope]
+	currentFunction FunctionType
+	currentClass    ClassType
+	// Number of loops enclosing the current statement within the current function
+	loopDepth int
+	diag      *diagnostics.Collector
+}
+
+func NewResolver(locals Locals, diag *diagnostics.Collector) *Resolver {
+	return &Resolver{
+		locals:          locals,
+		diag:            diag,
+		scopes:          utils.NewStack[scope](),
+		currentFunction: NONE,
+		currentClass:    NO_CLASS,
+	}
+}
+
+// Resolve Resolves all the statements.
+// Static errors are reported to the collector and resolving continues
+func (r *Resolver) Resolve(stmts []parser.Stmt) {
+	for _, stmt := range stmts {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt parser.Stmt) {
+	stmt.Visit(r)
+}
+
+func (r *Resolver) resolveExpr(expr parser.Expr) {
+	expr.Visit(r)
+}
+
+func (r *Resolver) error(name token.Token, message string) {
+	r.diag.Error(diagnostics.RESOLVE_ERROR, name.Span(), message)
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes.Push(make(scope))
+}
+
+func (r *Resolver) endScope() {
+	r.scopes.Pop()
+}
+
+// declare Adds the variable to the innermost scope and marks it as not ready
+func (r *Resolver) declare(name token.Token) {
+	if r.scopes.IsEmpty() {
+		return
+	}
+
+	scope := r.scopes.Top()
+	if _, ok := scope[name.Lexeme]; ok {
+		r.error(name, "Already a variable with this name in this scope.")
+	}
+	scope[name.Lexeme] = &variable{slot: len(scope)}
+}
+
+// define Marks the variable as initialized and ready for use
+func (r *Resolver) define(name token.Token) {
+	if r.scopes.IsEmpty() {
+		return
+	}
+
+	r.scopes.Top()[name.Lexeme].ready = true
+}
+
+// resolveLocal Finds the innermost scope which has the variable and passes
+// the number of hops and its slot to the interpreter.
+// If it is not found, the variable is assumed to be global
+func (r *Resolver) resolveLocal(expr parser.Expr, name token.Token) {
+	depth, slot := -1, 0
+	next, has := r.scopes.Itr()
+	for i := 0; has(); i++ {
+		if v, ok := next()[name.Lexeme]; ok {
+			depth, slot = r.scopes.Length()-1-i, v.slot
+		}
+	}
+
+	if depth >= 0 {
+		r.locals.Resolve(expr, depth, slot)
+	}
+}
+
+func (r *Resolver) resolveFunction(function *parser.Function, functionType FunctionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = functionType
+	defer func() { r.currentFunction = enclosingFunction }()
+
+	// Loops outside the function can't be exited from inside it
+	enclosingLoopDepth := r.loopDepth
+	r.loopDepth = 0
+	defer func() { r.loopDepth = enclosingLoopDepth }()
+
+	r.beginScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.Resolve(function.Body)
+	r.endScope()
+}
+
+// Statements
+func (r *Resolver) VisitBlockStmt(b *parser.Block) (interface{}, error) {
+	r.beginScope()
+	r.Resolve(b.Statements)
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitBreakStmt(b *parser.Break) (interface{}, error) {
+	if r.loopDepth == 0 {
+		r.error(b.Keyword, "Can't use 'break' outside of a loop.")
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitClassStmt(c *parser.Class) (interface{}, error) {
+	enclosingClass := r.currentClass
+	r.currentClass = CLASS
+	defer func() { r.currentClass = enclosingClass }()
+
+	r.declare(c.Name)
+	r.define(c.Name)
+
+	if c.Superclass != nil {
+		if c.Superclass.Name.Lexeme == c.Name.Lexeme {
+			r.error(c.Superclass.Name, "A class can't inherit from itself.")
+		}
+
+		r.currentClass = SUBCLASS
+		r.resolveExpr(c.Superclass)
+
+		// Scope which holds "super" encloses the scope which holds "this"
+		r.beginScope()
+		r.scopes.Top()["super"] = &variable{slot: 0, ready: true}
+		defer r.endScope()
+	}
+
+	// Methods are resolved inside a scope which holds "this"
+	r.beginScope()
+	r.scopes.Top()["this"] = &variable{slot: 0, ready: true}
+	for _, method := range c.Methods {
+		functionType := METHOD
+		if method.Name.Lexeme == "init" {
+			functionType = INITIALIZER
+		}
+		r.resolveFunction(method, functionType)
+	}
+	r.endScope()
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitContinueStmt(c *parser.Continue) (interface{}, error) {
+	if r.loopDepth == 0 {
+		r.error(c.Keyword, "Can't use 'continue' outside of a loop.")
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitExpressionStmt(e *parser.Expression) (interface{}, error) {
+	r.resolveExpr(e.Expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitForInStmt(f *parser.ForIn) (interface{}, error) {
+	r.resolveExpr(f.Iterable)
+
+	// Loop variable lives in its own scope around the body
+	r.beginScope()
+	r.declare(f.Name)
+	r.define(f.Name)
+
+	r.loopDepth++
+	r.resolveStmt(f.Body)
+	r.loopDepth--
+
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitFunctionStmt(f *parser.Function) (interface{}, error) {
+	// Function is defined before the body is resolved to allow recursion
+	r.declare(f.Name)
+	r.define(f.Name)
+
+	r.resolveFunction(f, FUNCTION)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIfStmt(i *parser.If) (interface{}, error) {
+	r.resolveExpr(i.Condition)
+	r.resolveStmt(i.ThenBranch)
+	if i.ElseBranch != nil {
+		r.resolveStmt(i.ElseBranch)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitImportStmt(i *parser.Import) (interface{}, error) {
+	r.declare(i.Name)
+	r.define(i.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitPrintStmt(p *parser.Print) (interface{}, error) {
+	r.resolveExpr(p.Expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitReturnStmt(ret *parser.Return) (interface{}, error) {
+	if r.currentFunction == NONE {
+		r.error(ret.Keyword, "Can't return from top-level code.")
+	}
+
+	if ret.Value != nil {
+		if r.currentFunction == INITIALIZER {
+			r.error(ret.Keyword, "Can't return a value from an initializer.")
+		}
+		r.resolveExpr(ret.Value)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitThrowStmt(t *parser.Throw) (interface{}, error) {
+	r.resolveExpr(t.Value)
+	return nil, nil
+}
+
+func (r *Resolver) VisitTryStmt(t *parser.Try) (interface{}, error) {
+	r.beginScope()
+	r.Resolve(t.Body)
+	r.endScope()
+
+	// Error variable is in the same scope as the catch body
+	if t.CatchBody != nil {
+		r.beginScope()
+		r.declare(t.CatchName)
+		r.define(t.CatchName)
+		r.Resolve(t.CatchBody)
+		r.endScope()
+	}
+
+	if t.FinallyBody != nil {
+		r.beginScope()
+		r.Resolve(t.FinallyBody)
+		r.endScope()
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitVarStmt(v *parser.Var) (interface{}, error) {
+	r.declare(v.Name)
+	if v.Initializer != nil {
+		r.resolveExpr(v.Initializer)
+	}
+	r.define(v.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitWhileStmt(w *parser.While) (interface{}, error) {
+	r.resolveExpr(w.Condition)
+	if w.Increment != nil {
+		r.resolveExpr(w.Increment)
+	}
+
+	r.loopDepth++
+	r.resolveStmt(w.Body)
+	r.loopDepth--
+	return nil, nil
+}
+
+// Expressions
+func (r *Resolver) VisitAssignExpr(a *parser.Assign) (interface{}, error) {
+	r.resolveExpr(a.Value)
+	r.resolveLocal(a, a.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinaryExpr(b *parser.Binary) (interface{}, error) {
+	r.resolveExpr(b.Left)
+	r.resolveExpr(b.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(c *parser.Call) (interface{}, error) {
+	r.resolveExpr(c.Callee)
+	for _, argument := range c.Arguments {
+		r.resolveExpr(argument)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitGetExpr(g *parser.Get) (interface{}, error) {
+	// Properties are looked up dynamically, only the object is resolved
+	r.resolveExpr(g.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(g *parser.Grouping) (interface{}, error) {
+	r.resolveExpr(g.Expression)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpr(i *parser.Index) (interface{}, error) {
+	r.resolveExpr(i.Object)
+	r.resolveExpr(i.Index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLambdaExpr(l *parser.Lambda) (interface{}, error) {
+	r.resolveFunction(l.Function, FUNCTION)
+	return nil, nil
+}
+
+func (r *Resolver) VisitListExpr(l *parser.List) (interface{}, error) {
+	for _, element := range l.Elements {
+		r.resolveExpr(element)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteralExpr(l *parser.Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(l *parser.Logical) (interface{}, error) {
+	r.resolveExpr(l.Left)
+	r.resolveExpr(l.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(m *parser.Map) (interface{}, error) {
+	for k := range m.Keys {
+		r.resolveExpr(m.Keys[k])
+		r.resolveExpr(m.Values[k])
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitSetExpr(s *parser.Set) (interface{}, error) {
+	r.resolveExpr(s.Value)
+	r.resolveExpr(s.Object)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSetIndexExpr(s *parser.SetIndex) (interface{}, error) {
+	r.resolveExpr(s.Value)
+	r.resolveExpr(s.Object)
+	r.resolveExpr(s.Index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuperExpr(s *parser.Super) (interface{}, error) {
+	if r.currentClass == NO_CLASS {
+		r.error(s.Keyword, "Can't use 'super' outside of a class.")
+		return nil, nil
+	} else if r.currentClass != SUBCLASS {
+		r.error(s.Keyword, "Can't use 'super' in a class with no superclass.")
+		return nil, nil
+	}
+
+	r.resolveLocal(s, s.Keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitThisExpr(t *parser.This) (interface{}, error) {
+	if r.currentClass == NO_CLASS {
+		r.error(t.Keyword, "Can't use 'this' outside of a class.")
+		return nil, nil
+	}
+
+	r.resolveLocal(t, t.Keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(u *parser.Unary) (interface{}, error) {
+	r.resolveExpr(u.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVariableExpr(v *parser.Variable) (interface{}, error) {
+	if !r.scopes.IsEmpty() {
+		if declared, ok := r.scopes.Top()[v.Name.Lexeme]; ok && !declared.ready {
+			r.error(v.Name, "Can't read local variable in its own initializer.")
+		}
+	}
+
+	r.resolveLocal(v, v.Name)
+	return nil, nil
+}
@@ -3,28 +3,38 @@ package scanner
 import (
 	"strconv"
 
+	"github.com/madraceee/interpreters/glox/diagnostics"
 	"github.com/madraceee/interpreters/glox/token"
-	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
 )
 
 var (
 	keywords map[string]token.TokenType = map[string]token.TokenType{
-		"and":    token.AND,
-		"class":  token.CLASS,
-		"else":   token.ELSE,
-		"false":  token.FALSE,
-		"fun":    token.FUN,
-		"for":    token.FOR,
-		"if":     token.IF,
-		"nil":    token.NIL,
-		"or":     token.OR,
-		"print":  token.PRINT,
-		"return": token.RETURN,
-		"super":  token.SUPER,
-		"this":   token.THIS,
-		"true":   token.TRUE,
-		"var":    token.VAR,
-		"while":  token.WHILE,
+		"and":      token.AND,
+		"as":       token.AS,
+		"break":    token.BREAK,
+		"catch":    token.CATCH,
+		"class":    token.CLASS,
+		"continue": token.CONTINUE,
+		"else":     token.ELSE,
+		"false":    token.FALSE,
+		"finally":  token.FINALLY,
+		"fun":      token.FUN,
+		"for":      token.FOR,
+		"if":       token.IF,
+		"import":   token.IMPORT,
+		"in":       token.IN,
+		"nil":      token.NIL,
+		"or":       token.OR,
+		"print":    token.PRINT,
+		"return":   token.RETURN,
+		"super":    token.SUPER,
+		"this":     token.THIS,
+		"throw":    token.THROW,
+		"true":     token.TRUE,
+		"try":      token.TRY,
+		"var":      token.VAR,
+		"while":    token.WHILE,
 	}
 )
 
@@ -38,24 +48,36 @@ type Scan struct {
 	start   int
 	current int
 	line    int
+	// Offset of the first character of the current line
+	lineStart int
+	// Line and column at which the current token starts
+	startLine int
+	column    int
+	diag      *diagnostics.Collector
 }
 
-func NewScanner(source string) *Scan {
+func NewScanner(source string, diag *diagnostics.Collector) *Scan {
 	return &Scan{
 		source:  source,
 		start:   0,
 		current: 0,
 		line:    1,
+		diag:    diag,
 	}
 }
 
 func (s *Scan) ScanTokens() []token.Token {
 	for !s.isAtEnd() {
 		s.start = s.current
+		s.startLine = s.line
+		s.column = s.current - s.lineStart + 1
 		s.scanToken()
 	}
 
-	s.tokens = append(s.tokens, token.NewToken(token.EOF, "", token.Object{}, s.line))
+	s.start = s.current
+	s.startLine = s.line
+	s.column = s.current - s.lineStart + 1
+	s.addToken(token.EOF)
 	return s.tokens
 }
 
@@ -74,6 +96,12 @@ func (s *Scan) scanToken() {
 		s.addToken(token.LEFT_BRACE)
 	case '}':
 		s.addToken(token.RIGHT_BRACE)
+	case '[':
+		s.addToken(token.LEFT_BRACKET)
+	case ']':
+		s.addToken(token.RIGHT_BRACKET)
+	case ':':
+		s.addToken(token.COLON)
 	case ',':
 		s.addToken(token.COMMA)
 	case '.':
@@ -95,6 +123,8 @@ func (s *Scan) scanToken() {
 	case '=':
 		if s.match('=') {
 			s.addToken(token.EQUAL_EQUAL)
+		} else if s.match('>') {
+			s.addToken(token.ARROW)
 		} else {
 			s.addToken(token.EQUAL)
 		}
@@ -119,7 +149,7 @@ func (s *Scan) scanToken() {
 			count := 1
 			for count > 0 {
 				if s.isAtEnd() {
-					utils.Error(s.line, "Multi line comment is not closed")
+					s.diag.Error(diagnostics.SCAN_ERROR, s.span(), "Multi line comment is not closed")
 					break
 				}
 				if s.peek() == '*' && s.peekNext() == '/' {
@@ -133,7 +163,7 @@ func (s *Scan) scanToken() {
 				}
 
 				if s.peek() == '\n' {
-					s.line++
+					s.newLine()
 				}
 				s.advance()
 			}
@@ -144,6 +174,7 @@ func (s *Scan) scanToken() {
 		break
 	case '\n':
 		s.line++
+		s.lineStart = s.current
 	case '"':
 		s.string()
 	default:
@@ -152,7 +183,7 @@ func (s *Scan) scanToken() {
 		} else if isAlpha(c) {
 			s.identifier()
 		} else {
-			utils.Error(s.line, "Unexpected character.")
+			s.diag.Error(diagnostics.SCAN_ERROR, s.span(), "Unexpected character.")
 		}
 	}
 }
@@ -179,6 +210,12 @@ func (s *Scan) match(expected rune) bool {
 	return true
 }
 
+// newLine Moves to the next line when the character at s.current is a new line
+func (s *Scan) newLine() {
+	s.line++
+	s.lineStart = s.current + 1
+}
+
 // peek Returns the characeter that is present at s.current
 func (s *Scan) peek() rune {
 	if s.isAtEnd() {
@@ -221,11 +258,8 @@ func (s *Scan) number() {
 		}
 	}
 
-	value, _ := strconv.ParseFloat(s.source[s.start:s.current], 64)
-	s.addTokenObj(token.NUMBER, token.Object{
-		ObjType:     token.NUMBER_TYPE,
-		Value_float: value,
-	})
+	number, _ := strconv.ParseFloat(s.source[s.start:s.current], 64)
+	s.addTokenObj(token.NUMBER, value.Number(number))
 }
 
 func (s *Scan) identifier() {
@@ -233,8 +267,8 @@ func (s *Scan) identifier() {
 		s.advance()
 	}
 
-	value := s.source[s.start:s.current]
-	tokenType, ok := keywords[value]
+	text := s.source[s.start:s.current]
+	tokenType, ok := keywords[text]
 	if !ok {
 		tokenType = token.IDENTIFIER
 	}
@@ -244,30 +278,46 @@ func (s *Scan) identifier() {
 func (s *Scan) string() {
 	for s.peek() != '"' && !s.isAtEnd() {
 		if s.peek() == '\n' {
-			s.line += 1
+			s.newLine()
 		}
 		s.advance()
 	}
 
 	if s.isAtEnd() {
-		utils.Error(s.line, "Unterminated String.")
+		s.diag.Error(diagnostics.SCAN_ERROR, s.span(), "Unterminated String.")
 		return
 	}
 
 	s.advance()
 
-	value := s.source[s.start+1 : s.current-1]
-	s.addTokenObj(token.STRING, token.Object{
-		ObjType:   token.STRING_TYPE,
-		Value_str: value,
-	})
+	str := s.source[s.start+1 : s.current-1]
+	s.addTokenObj(token.STRING, value.String(str))
 }
 
 func (s *Scan) addToken(tokenType token.TokenType) {
-	s.addTokenObj(tokenType, token.Object{})
+	s.addTokenObj(tokenType, nil)
 }
 
-func (s *Scan) addTokenObj(tokenType token.TokenType, object token.Object) {
+func (s *Scan) addTokenObj(tokenType token.TokenType, literal value.Value) {
 	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, token.NewToken(tokenType, text, object, s.line))
+	tok := token.NewToken(tokenType, text, literal, s.startLine, s.column)
+	tok.Offset = s.start
+	tok.End = s.span().End
+	s.tokens = append(s.tokens, tok)
+}
+
+// span Returns the span from the start of the current token to s.current
+func (s *Scan) span() token.Span {
+	return token.Span{
+		Start: token.Position{
+			Offset: s.start,
+			Line:   s.startLine,
+			Column: s.column,
+		},
+		End: token.Position{
+			Offset: s.current,
+			Line:   s.line,
+			Column: s.current - s.lineStart + 1,
+		},
+	}
 }
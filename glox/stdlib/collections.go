@@ -0,0 +1,132 @@
+package stdlib
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Collections Functions to work with lists, maps and ranges
+var Collections = &Group{
+	Name: "collections",
+	Functions: []*NativeFunction{
+		{Name: "len", Arity: 1, Fn: nativeLen},
+		{Name: "push", Arity: 2, Fn: nativePush},
+		{Name: "pop", Arity: 1, Fn: nativePop},
+		{Name: "slice", Arity: 3, Fn: nativeSlice},
+		{Name: "has", Arity: 2, Fn: nativeHas},
+		{Name: "delete", Arity: 2, Fn: nativeDelete},
+		{Name: "keys", Arity: 1, Fn: nativeKeys},
+		{Name: "values", Arity: 1, Fn: nativeValues},
+		{Name: "range", Arity: 2, Fn: nativeRange},
+	},
+}
+
+// nativeLen Returns the number of elements of a list, entries
+// of a map or characters of a string, which for-in visits one by one
+func nativeLen(arguments []value.Value) (value.Value, error) {
+	switch v := arguments[0].(type) {
+	case *value.List:
+		return value.Number(len(v.Elements)), nil
+	case *value.Map:
+		return value.Number(v.Len()), nil
+	case value.String:
+		return value.Number(utf8.RuneCountInString(string(v))), nil
+	}
+	return nil, errors.New("Argument to len must be a list, a map or a string.")
+}
+
+// nativePush Appends the value to the end of the list
+func nativePush(arguments []value.Value) (value.Value, error) {
+	list, ok := value.AsList(arguments[0])
+	if !ok {
+		return nil, errors.New("First argument to push must be a list.")
+	}
+
+	list.Elements = append(list.Elements, arguments[1])
+	return value.Nil, nil
+}
+
+// nativePop Removes and returns the last element of the list
+func nativePop(arguments []value.Value) (value.Value, error) {
+	list, ok := value.AsList(arguments[0])
+	if !ok {
+		return nil, errors.New("Argument to pop must be a list.")
+	}
+	if len(list.Elements) == 0 {
+		return nil, errors.New("Can't pop from an empty list.")
+	}
+
+	last := list.Elements[len(list.Elements)-1]
+	list.Elements = list.Elements[:len(list.Elements)-1]
+	return last, nil
+}
+
+// nativeSlice Returns a new list with the elements from start up to but not including end
+func nativeSlice(arguments []value.Value) (value.Value, error) {
+	list, ok := value.AsList(arguments[0])
+	if !ok {
+		return nil, errors.New("First argument to slice must be a list.")
+	}
+
+	start, startErr := integer("slice", 2, arguments[1])
+	end, endErr := integer("slice", 3, arguments[2])
+	if startErr != nil || endErr != nil {
+		return nil, errors.New("Slice bounds must be integers.")
+	}
+	if start < 0 || end > len(list.Elements) || start > end {
+		return nil, errors.New("Slice bounds out of range.")
+	}
+
+	// Copied so the slice doesn't share elements with the list
+	elements := make([]value.Value, end-start)
+	copy(elements, list.Elements[start:end])
+	return value.NewList(elements), nil
+}
+
+// nativeHas Reports whether the map has the key
+func nativeHas(arguments []value.Value) (value.Value, error) {
+	m, ok := value.AsMap(arguments[0])
+	if !ok {
+		return nil, errors.New("First argument to has must be a map.")
+	}
+	return value.Bool(m.Has(arguments[1])), nil
+}
+
+// nativeDelete Removes the key from the map and reports whether it was present
+func nativeDelete(arguments []value.Value) (value.Value, error) {
+	m, ok := value.AsMap(arguments[0])
+	if !ok {
+		return nil, errors.New("First argument to delete must be a map.")
+	}
+	return value.Bool(m.Delete(arguments[1])), nil
+}
+
+// nativeKeys Returns a list of the keys of the map in insertion order
+func nativeKeys(arguments []value.Value) (value.Value, error) {
+	m, ok := value.AsMap(arguments[0])
+	if !ok {
+		return nil, errors.New("Argument to keys must be a map.")
+	}
+	return value.NewList(m.Keys()), nil
+}
+
+// nativeValues Returns a list of the values of the map in insertion order
+func nativeValues(arguments []value.Value) (value.Value, error) {
+	m, ok := value.AsMap(arguments[0])
+	if !ok {
+		return nil, errors.New("Argument to values must be a map.")
+	}
+	return value.NewList(m.Values()), nil
+}
+
+// nativeRange Returns the numbers from start up to but not including end
+func nativeRange(arguments []value.Value) (value.Value, error) {
+	start, startOk := value.AsNumber(arguments[0])
+	end, endOk := value.AsNumber(arguments[1])
+	if !startOk || !endOk {
+		return nil, errors.New("Arguments to range must be numbers.")
+	}
+	return value.Range{Start: start, End: end}, nil
+}
@@ -0,0 +1,61 @@
+package stdlib
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// IO Returns functions which read lines from in and write to out
+func IO(in io.Reader, out io.Writer) *Group {
+	reader := bufio.NewReader(in)
+
+	// write Writes the text form of the values separated by spaces
+	write := func(arguments []value.Value, end string) (value.Value, error) {
+		parts := make([]string, 0, len(arguments))
+		for _, argument := range arguments {
+			parts = append(parts, value.ToString(argument))
+		}
+		if _, err := io.WriteString(out, strings.Join(parts, " ")+end); err != nil {
+			return nil, err
+		}
+		return value.Nil, nil
+	}
+
+	return &Group{
+		Name: "io",
+		Functions: []*NativeFunction{
+			{
+				Name:  "write",
+				Arity: VARIADIC,
+				Fn: func(arguments []value.Value) (value.Value, error) {
+					return write(arguments, "")
+				},
+			},
+			{
+				Name:  "writeLine",
+				Arity: VARIADIC,
+				Fn: func(arguments []value.Value) (value.Value, error) {
+					return write(arguments, "\n")
+				},
+			},
+			{
+				// readLine Returns the next line without its newline, or nil at the end of the input
+				Name:  "readLine",
+				Arity: 0,
+				Fn: func(arguments []value.Value) (value.Value, error) {
+					line, err := reader.ReadString('\n')
+					if err == io.EOF && line == "" {
+						return value.Nil, nil
+					}
+					if err != nil && err != io.EOF {
+						return nil, err
+					}
+					return value.String(strings.TrimRight(line, "\r\n")), nil
+				},
+			},
+		},
+	}
+}
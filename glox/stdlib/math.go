@@ -0,0 +1,74 @@
+package stdlib
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Math Numeric functions
+var Math = &Group{
+	Name: "math",
+	Functions: []*NativeFunction{
+		unaryMath("abs", math.Abs),
+		unaryMath("ceil", math.Ceil),
+		unaryMath("floor", math.Floor),
+		unaryMath("round", math.Round),
+		unaryMath("sqrt", math.Sqrt),
+		unaryMath("sin", math.Sin),
+		unaryMath("cos", math.Cos),
+		{Name: "pow", Arity: 2, Fn: nativePow},
+		{Name: "min", Arity: VARIADIC, Fn: extremum("min", math.Min)},
+		{Name: "max", Arity: VARIADIC, Fn: extremum("max", math.Max)},
+		{Name: "random", Arity: 0, Fn: nativeRandom},
+	},
+}
+
+// unaryMath Wraps a Go function of one number
+func unaryMath(name string, fn func(float64) float64) *NativeFunction {
+	return &NativeFunction{
+		Name:  name,
+		Arity: 1,
+		Fn: func(arguments []value.Value) (value.Value, error) {
+			n, err := numbers(name, arguments)
+			if err != nil {
+				return nil, err
+			}
+			return value.Number(fn(n[0])), nil
+		},
+	}
+}
+
+func nativePow(arguments []value.Value) (value.Value, error) {
+	n, err := numbers("pow", arguments)
+	if err != nil {
+		return nil, err
+	}
+	return value.Number(math.Pow(n[0], n[1])), nil
+}
+
+// extremum Returns a function which folds one or more numbers with pick
+func extremum(name string, pick func(a, b float64) float64) func([]value.Value) (value.Value, error) {
+	return func(arguments []value.Value) (value.Value, error) {
+		if len(arguments) == 0 {
+			return nil, errors.New(name + " expects at least 1 argument.")
+		}
+		n, err := numbers(name, arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		result := n[0]
+		for _, number := range n[1:] {
+			result = pick(result, number)
+		}
+		return value.Number(result), nil
+	}
+}
+
+// nativeRandom Returns a number in [0, 1)
+func nativeRandom(arguments []value.Value) (value.Value, error) {
+	return value.Number(rand.Float64()), nil
+}
@@ -0,0 +1,100 @@
+package stdlib
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// VARIADIC Arity of a native function which takes any number of arguments
+const VARIADIC = -1
+
+// NativeFunction A function implemented in Go.
+// Errors it returns are reported as runtime errors at the call
+type NativeFunction struct {
+	Name string
+	// Number of arguments, or VARIADIC to let Fn check them
+	Arity int
+	Fn    func(arguments []value.Value) (value.Value, error)
+	// Set instead of Fn by functions which block, so they
+	// return as soon as the script is stopped
+	FnContext func(ctx context.Context, arguments []value.Value) (value.Value, error)
+}
+
+// Call Calls the function, giving the context of the script to those which block
+func (n *NativeFunction) Call(ctx context.Context, arguments []value.Value) (value.Value, error) {
+	if n.FnContext != nil {
+		return n.FnContext(ctx, arguments)
+	}
+	return n.Fn(arguments)
+}
+
+func (n *NativeFunction) Type() value.Type {
+	return value.CALLABLE_TYPE
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// Group A named set of native functions installed together
+type Group struct {
+	Name      string
+	Functions []*NativeFunction
+}
+
+// Registrar Is implemented by the interpreter to make natives callable from Lox
+type Registrar interface {
+	DefineNative(fn *NativeFunction)
+}
+
+// Install Defines every function of the groups
+func Install(r Registrar, groups ...*Group) {
+	for _, group := range groups {
+		for _, fn := range group.Functions {
+			r.DefineNative(fn)
+		}
+	}
+}
+
+// Groups Returns every group of the standard library.
+// io functions read from in and write to out
+func Groups(in io.Reader, out io.Writer) []*Group {
+	return []*Group{
+		Collections,
+		Math,
+		String,
+		Time,
+		IO(in, out),
+	}
+}
+
+// argumentError Returns the error for an argument of the wrong type
+func argumentError(fn string, position int, want string) error {
+	return errors.New("Argument " + strconv.Itoa(position) + " to " + fn + " must be " + want + ".")
+}
+
+// numbers Returns the arguments as numbers or an error naming the first which is not
+func numbers(fn string, arguments []value.Value) ([]float64, error) {
+	result := make([]float64, 0, len(arguments))
+	for k, argument := range arguments {
+		number, ok := value.AsNumber(argument)
+		if !ok {
+			return nil, argumentError(fn, k+1, "a number")
+		}
+		result = append(result, number)
+	}
+	return result, nil
+}
+
+// integer Returns the argument as an int if it is a whole number
+func integer(fn string, position int, argument value.Value) (int, error) {
+	number, ok := value.AsNumber(argument)
+	if !ok || number != float64(int(number)) {
+		return 0, argumentError(fn, position, "an integer")
+	}
+	return int(number), nil
+}
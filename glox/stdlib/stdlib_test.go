@@ -0,0 +1,144 @@
+package stdlib
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// registry Records the natives installed into it
+type registry map[string]*NativeFunction
+
+func (r registry) DefineNative(fn *NativeFunction) {
+	r[fn.Name] = fn
+}
+
+func call(t *testing.T, r registry, name string, arguments ...value.Value) (value.Value, error) {
+	t.Helper()
+	fn, ok := r[name]
+	if !ok {
+		t.Fatalf("%s is not installed", name)
+	}
+	if fn.Arity != VARIADIC && fn.Arity != len(arguments) {
+		t.Fatalf("%s takes %d arguments, got %d", name, fn.Arity, len(arguments))
+	}
+	return fn.Call(context.Background(), arguments)
+}
+
+func TestInstall(t *testing.T) {
+	r := registry{}
+	Install(r, Groups(strings.NewReader(""), &bytes.Buffer{})...)
+
+	for _, name := range []string{"len", "range", "sqrt", "max", "upper", "split", "clock", "writeLine", "readLine"} {
+		if _, ok := r[name]; !ok {
+			t.Errorf("%s is not installed", name)
+		}
+	}
+}
+
+func TestNatives(t *testing.T) {
+	r := registry{}
+	Install(r, Collections, Math, String, Time)
+
+	tests := []struct {
+		name      string
+		arguments []value.Value
+		want      string
+	}{
+		{"sqrt", []value.Value{value.Number(9)}, "3"},
+		{"pow", []value.Value{value.Number(2), value.Number(10)}, "1024"},
+		{"min", []value.Value{value.Number(3), value.Number(-1), value.Number(2)}, "-1"},
+		{"max", []value.Value{value.Number(3)}, "3"},
+		{"upper", []value.Value{value.String("abc")}, "ABC"},
+		{"substring", []value.Value{value.String("hello"), value.Number(1), value.Number(4)}, "ell"},
+		{"split", []value.Value{value.String("a b"), value.String(" ")}, "[a, b]"},
+		{"join", []value.Value{value.NewList([]value.Value{value.Number(1), value.Nil}), value.String(",")}, "1,nil"},
+		{"num", []value.Value{value.String(" 2.5 ")}, "2.5"},
+		{"str", []value.Value{value.Bool(true)}, "true"},
+		{"len", []value.Value{value.String("abcd")}, "4"},
+		{"len", []value.Value{value.String("héllo")}, "5"},
+		{"substring", []value.Value{value.String("héllo"), value.Number(1), value.Number(3)}, "él"},
+		{"indexOf", []value.Value{value.String("héllo"), value.String("l")}, "2"},
+		{"indexOf", []value.Value{value.String("héllo"), value.String("x")}, "-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := call(t, r, tt.name, tt.arguments...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value.ToString(got) != tt.want {
+				t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeErrors(t *testing.T) {
+	r := registry{}
+	Install(r, Collections, Math, String)
+
+	tests := []struct {
+		name      string
+		arguments []value.Value
+	}{
+		{"sqrt", []value.Value{value.String("9")}},
+		{"max", nil},
+		{"upper", []value.Value{value.Number(1)}},
+		{"num", []value.Value{value.String("abc")}},
+		{"substring", []value.Value{value.String("abc"), value.Number(2), value.Number(5)}},
+		{"pop", []value.Value{value.NewList(nil)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := call(t, r, tt.name, tt.arguments...); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestClockReturnsSeconds(t *testing.T) {
+	r := registry{}
+	Install(r, Time)
+
+	got, err := call(t, r, "clock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seconds, ok := value.AsNumber(got)
+	if !ok {
+		t.Fatalf("clock returned %T, want a number", got)
+	}
+	// Any time after 2020 and before 2100
+	if seconds < 1.5e9 || seconds > 4.2e9 {
+		t.Errorf("clock = %v, want seconds since the Unix epoch", seconds)
+	}
+}
+
+func TestIO(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := registry{}
+	Install(r, IO(strings.NewReader("first\r\nsecond"), out))
+
+	for _, want := range []string{"first", "second", "nil"} {
+		got, err := call(t, r, "readLine")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if value.ToString(got) != want {
+			t.Errorf("readLine = %v, want %v", got, want)
+		}
+	}
+
+	call(t, r, "write", value.String("a"), value.Number(1))
+	call(t, r, "writeLine", value.Nil)
+	if got := out.String(); got != "a 1nil\n" {
+		t.Errorf("output = %q, want %q", got, "a 1nil\n")
+	}
+}
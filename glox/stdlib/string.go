@@ -0,0 +1,151 @@
+package stdlib
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// String Functions to work with strings. Positions count characters,
+// the Unicode code points a for-in loop visits, not bytes
+var String = &Group{
+	Name: "string",
+	Functions: []*NativeFunction{
+		{Name: "str", Arity: 1, Fn: nativeStr},
+		{Name: "num", Arity: 1, Fn: nativeNum},
+		unaryString("upper", strings.ToUpper),
+		unaryString("lower", strings.ToLower),
+		unaryString("trim", strings.TrimSpace),
+		{Name: "substring", Arity: 3, Fn: nativeSubstring},
+		{Name: "indexOf", Arity: 2, Fn: nativeIndexOf},
+		{Name: "replace", Arity: 3, Fn: nativeReplace},
+		{Name: "split", Arity: 2, Fn: nativeSplit},
+		{Name: "join", Arity: 2, Fn: nativeJoin},
+	},
+}
+
+// stringArguments Returns the arguments as strings or an error naming the first which is not
+func stringArguments(fn string, arguments []value.Value) ([]string, error) {
+	result := make([]string, 0, len(arguments))
+	for k, argument := range arguments {
+		s, ok := value.AsString(argument)
+		if !ok {
+			return nil, argumentError(fn, k+1, "a string")
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// unaryString Wraps a Go function of one string
+func unaryString(name string, fn func(string) string) *NativeFunction {
+	return &NativeFunction{
+		Name:  name,
+		Arity: 1,
+		Fn: func(arguments []value.Value) (value.Value, error) {
+			s, err := stringArguments(name, arguments)
+			if err != nil {
+				return nil, err
+			}
+			return value.String(fn(s[0])), nil
+		},
+	}
+}
+
+// nativeStr Returns the text form of any value, as print shows it
+func nativeStr(arguments []value.Value) (value.Value, error) {
+	return value.String(value.ToString(arguments[0])), nil
+}
+
+// nativeNum Parses the string as a number
+func nativeNum(arguments []value.Value) (value.Value, error) {
+	s, err := stringArguments("num", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(s[0]), 64)
+	if err != nil {
+		return nil, errors.New("Can't convert '" + s[0] + "' to a number.")
+	}
+	return value.Number(number), nil
+}
+
+// nativeSubstring Returns the characters from start up to but not including end
+func nativeSubstring(arguments []value.Value) (value.Value, error) {
+	s, ok := value.AsString(arguments[0])
+	if !ok {
+		return nil, argumentError("substring", 1, "a string")
+	}
+	start, err := integer("substring", 2, arguments[1])
+	if err != nil {
+		return nil, err
+	}
+	end, err := integer("substring", 3, arguments[2])
+	if err != nil {
+		return nil, err
+	}
+	characters := []rune(s)
+	if start < 0 || end > len(characters) || start > end {
+		return nil, errors.New("Substring bounds out of range.")
+	}
+	return value.String(characters[start:end]), nil
+}
+
+// nativeIndexOf Returns the position of the first occurrence of the substring or -1
+func nativeIndexOf(arguments []value.Value) (value.Value, error) {
+	s, err := stringArguments("indexOf", arguments)
+	if err != nil {
+		return nil, err
+	}
+	index := strings.Index(s[0], s[1])
+	if index < 0 {
+		return value.Number(-1), nil
+	}
+	return value.Number(utf8.RuneCountInString(s[0][:index])), nil
+}
+
+// nativeReplace Replaces every occurrence of old with new
+func nativeReplace(arguments []value.Value) (value.Value, error) {
+	s, err := stringArguments("replace", arguments)
+	if err != nil {
+		return nil, err
+	}
+	return value.String(strings.ReplaceAll(s[0], s[1], s[2])), nil
+}
+
+// nativeSplit Returns a list of the parts of the string around the separator
+func nativeSplit(arguments []value.Value) (value.Value, error) {
+	s, err := stringArguments("split", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(s[0], s[1])
+	elements := make([]value.Value, 0, len(parts))
+	for _, part := range parts {
+		elements = append(elements, value.String(part))
+	}
+	return value.NewList(elements), nil
+}
+
+// nativeJoin Joins the text form of the elements of the list with the separator
+func nativeJoin(arguments []value.Value) (value.Value, error) {
+	list, ok := value.AsList(arguments[0])
+	if !ok {
+		return nil, argumentError("join", 1, "a list")
+	}
+	separator, ok := value.AsString(arguments[1])
+	if !ok {
+		return nil, argumentError("join", 2, "a string")
+	}
+
+	parts := make([]string, 0, len(list.Elements))
+	for _, element := range list.Elements {
+		parts = append(parts, value.ToString(element))
+	}
+	return value.String(strings.Join(parts, separator)), nil
+}
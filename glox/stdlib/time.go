@@ -0,0 +1,39 @@
+package stdlib
+
+import (
+	"context"
+	"time"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Time Functions to measure and wait for time
+var Time = &Group{
+	Name: "time",
+	Functions: []*NativeFunction{
+		{Name: "clock", Arity: 0, Fn: nativeClock},
+		{Name: "sleep", Arity: 1, FnContext: nativeSleep},
+	},
+}
+
+// nativeClock Returns the seconds elapsed since the Unix epoch
+func nativeClock(arguments []value.Value) (value.Value, error) {
+	return value.Number(float64(time.Now().UnixNano()) / float64(time.Second)), nil
+}
+
+// nativeSleep Pauses for the number of seconds, or till the context is done
+func nativeSleep(ctx context.Context, arguments []value.Value) (value.Value, error) {
+	n, err := numbers("sleep", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(time.Duration(n[0] * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return value.Nil, nil
+	}
+}
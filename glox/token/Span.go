@@ -0,0 +1,34 @@
+package token
+
+// Position A location in the source.
+// Line and Column start from 1, Offset is the byte offset from 0
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Span The part of the source covered by a token or an AST node.
+// End is the position just after the last character
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Join Returns the span which starts at a and ends at b
+func Join(a, b Span) Span {
+	return Span{
+		Start: a.Start,
+		End:   b.End,
+	}
+}
+
+// FromSpan Returns a token covering the span, for errors raised at a whole node
+func FromSpan(span Span) Token {
+	return Token{
+		Line:   span.Start.Line,
+		Column: span.Start.Column,
+		Offset: span.Start.Offset,
+		End:    span.End,
+	}
+}
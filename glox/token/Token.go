@@ -1,54 +1,50 @@
 package token
 
-import "strconv"
-
-type ObjectType int
-
-const (
-	NOT_ASSIGNED_TYPE ObjectType = iota
-	NUMBER_TYPE
-	STRING_TYPE
-	BOOL_TYPE
-)
-
-type Object struct {
-	ObjType     ObjectType
-	Value_str   string
-	Value_float float64
-	Value_bool  bool
-}
-
-func GetStringValue(o Object) string {
-	if o.ObjType == NUMBER_TYPE {
-		return strconv.FormatFloat(o.Value_float, 'f', -1, 64)
-	} else if o.ObjType == STRING_TYPE {
-		return o.Value_str
-	} else if o.ObjType == BOOL_TYPE {
-		if o.Value_bool {
-			return "true"
-		} else {
-			return "false"
-		}
-	}
-	return ""
-}
+import "github.com/madraceee/interpreters/glox/value"
 
 type Token struct {
 	TokenType TokenType
 	Lexeme    string
-	Literal   Object
+	Literal   value.Value
 	Line      int
+	// Column of the first character of the lexeme, starting from 1
+	Column int
+	// Byte offset of the first character of the lexeme
+	Offset int
+	// Position just after the last character of the lexeme
+	End Position
 }
 
-func NewToken(tokenType TokenType, lexeme string, literal Object, line int) Token {
+func NewToken(tokenType TokenType, lexeme string, literal value.Value, line, column int) Token {
 	return Token{
 		TokenType: tokenType,
 		Lexeme:    lexeme,
 		Literal:   literal,
 		Line:      line,
+		Column:    column,
+		End: Position{
+			Line:   line,
+			Column: column + len(lexeme),
+		},
+	}
+}
+
+// Span Returns the part of the source covered by the token
+func (t Token) Span() Span {
+	return Span{
+		Start: Position{
+			Offset: t.Offset,
+			Line:   t.Line,
+			Column: t.Column,
+		},
+		End: t.End,
 	}
 }
 
 func (t Token) String() string {
-	return GetTokenType(t.TokenType) + " " + t.Lexeme + " " + GetStringValue(t.Literal)
+	literal := ""
+	if t.Literal != nil {
+		literal = t.Literal.String()
+	}
+	return GetTokenType(t.TokenType) + " " + t.Lexeme + " " + literal
 }
@@ -8,7 +8,10 @@ const (
 	RIGHT_PARAN
 	LEFT_BRACE
 	RIGHT_BRACE
+	LEFT_BRACKET
+	RIGHT_BRACKET
 	COMMA
+	COLON
 	DOT
 	MINUS
 	PLUS
@@ -25,6 +28,7 @@ const (
 	GREATER_EQUAL
 	LESS
 	LESS_EQUAL
+	ARROW
 
 	// Literals
 	IDENTIFIER
@@ -33,19 +37,28 @@ const (
 
 	// Keywords
 	AND
+	AS
+	BREAK
+	CATCH
 	CLASS
+	CONTINUE
 	ELSE
 	FALSE
+	FINALLY
 	FUN
 	FOR
 	IF
+	IMPORT
+	IN
 	NIL
 	OR
 	PRINT
 	RETURN
 	SUPER
 	THIS
+	THROW
 	TRUE
+	TRY
 	VAR
 	WHILE
 
@@ -62,8 +75,14 @@ func GetTokenType(tokenType TokenType) string {
 		return "LEFT_BRACE"
 	case RIGHT_BRACE:
 		return "RIGHT_BRACE"
+	case LEFT_BRACKET:
+		return "LEFT_BRACKET"
+	case RIGHT_BRACKET:
+		return "RIGHT_BRACKET"
 	case COMMA:
 		return "COMMA"
+	case COLON:
+		return "COLON"
 	case DOT:
 		return "DOT"
 	case MINUS:
@@ -92,6 +111,8 @@ func GetTokenType(tokenType TokenType) string {
 		return "LESS"
 	case LESS_EQUAL:
 		return "LESS_EQUAL"
+	case ARROW:
+		return "ARROW"
 	case IDENTIFIER:
 		return "IDENTIFIER"
 	case STRING:
@@ -100,18 +121,32 @@ func GetTokenType(tokenType TokenType) string {
 		return "NUMBER"
 	case AND:
 		return "AND"
+	case AS:
+		return "AS"
+	case BREAK:
+		return "BREAK"
+	case CATCH:
+		return "CATCH"
 	case CLASS:
 		return "CLASS"
+	case CONTINUE:
+		return "CONTINUE"
 	case ELSE:
 		return "ELSE"
 	case FALSE:
 		return "FALSE"
+	case FINALLY:
+		return "FINALLY"
 	case FUN:
 		return "FUN"
 	case FOR:
 		return "FOR"
 	case IF:
 		return "IF"
+	case IMPORT:
+		return "IMPORT"
+	case IN:
+		return "IN"
 	case NIL:
 		return "NIL"
 	case OR:
@@ -124,8 +159,12 @@ func GetTokenType(tokenType TokenType) string {
 		return "SUPER"
 	case THIS:
 		return "THIS"
+	case THROW:
+		return "THROW"
 	case TRUE:
 		return "TRUE"
+	case TRY:
+		return "TRY"
 	case VAR:
 		return "VAR"
 	case WHILE:
@@ -1,30 +1,64 @@
 package utils
 
 import (
-	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/madraceee/interpreters/glox/token"
 )
 
-var (
-	HadError        = false
-	HadRunTimeError = false
-)
+// StackFrame A single Lox call frame of a runtime error
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+	Column   int
+}
+
+func (f StackFrame) String() string {
+	return "at " + f.Function + " (" + f.File + ":" + strconv.Itoa(f.Line) + ":" + strconv.Itoa(f.Column) + ")"
+}
 
-func Error(line int, message string) {
-	Ereport(line, "", message)
+// RuntimeError Error raised while interpreting.
+// Trace is filled by the interpreter, innermost frame first
+type RuntimeError struct {
+	Token token.Token
+	Err   error
+	Trace []StackFrame
 }
 
-func TError(_token token.Token, message string) {
-	if _token.TokenType == token.EOF {
-		Ereport(_token.Line, "at end", message)
-	} else {
-		Ereport(_token.Line, "at '"+_token.Lexeme+"'", message)
+func (e *RuntimeError) Error() string {
+	builder := strings.Builder{}
+	builder.WriteString("Runtime Error: " + e.Err.Error() + "\n[Line " + strconv.Itoa(e.Token.Line) + "]\n")
+	for _, frame := range e.Trace {
+		builder.WriteString("  " + frame.String() + "\n")
 	}
+	return builder.String()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
 
+// Notes Returns the stack trace as the notes of a diagnostic.
+// Runs of the same frame, as left by a stack overflow, are shown once
+func (e *RuntimeError) Notes() []string {
+	notes := make([]string, 0, len(e.Trace))
+	for k := 0; k < len(e.Trace); {
+		frame := e.Trace[k]
+		notes = append(notes, frame.String())
+		repeated := 0
+		for k++; k < len(e.Trace) && e.Trace[k] == frame; k++ {
+			repeated++
+		}
+		if repeated > 0 {
+			notes = append(notes, "... repeated "+strconv.Itoa(repeated)+" more times")
+		}
+	}
+	return notes
 }
 
-func Ereport(line int, where, message string) {
-	fmt.Printf("[Line %d] Error %s: %s\n", line, where, message)
-	HadError = true
+// StackTrace Returns the Lox call stack at the point of the error, innermost frame first
+func (e *RuntimeError) StackTrace() []StackFrame {
+	return e.Trace
 }
@@ -0,0 +1,105 @@
+package value
+
+import "errors"
+
+// ErrNotIterable Is returned by Iterate for a value a for-in loop can't go over
+var ErrNotIterable = errors.New("Can only iterate over strings, lists, maps, ranges and iterable instances.")
+
+// Iterator Returns the next value of a for-in loop and false
+// once there are no values left. The VM keeps it in a hidden local of the loop
+type Iterator func() (Value, bool, error)
+
+func (it Iterator) Type() Type {
+	return CALLABLE_TYPE
+}
+
+func (it Iterator) String() string {
+	return "<iterator>"
+}
+
+// MethodCaller Calls the method of an instance without arguments
+type MethodCaller func(instance Value, name string) (Value, error)
+
+// Iterate Returns an iterator over the value.
+// Strings yield their characters, lists their elements, maps their keys
+// and ranges their numbers. Instances are iterated with the object returned
+// by their iter() method, which is either iterable itself or an instance
+// with hasNext() and next() methods
+func Iterate(iterable Value, callMethod MethodCaller) (Iterator, error) {
+	switch it := iterable.(type) {
+	case String:
+		characters := []rune(string(it))
+		position := 0
+		return func() (Value, bool, error) {
+			if position >= len(characters) {
+				return nil, false, nil
+			}
+			position++
+			return String(characters[position-1]), true, nil
+		}, nil
+
+	case *List:
+		// The length is read every step so elements pushed in the loop are visited
+		position := 0
+		return func() (Value, bool, error) {
+			if position >= len(it.Elements) {
+				return nil, false, nil
+			}
+			position++
+			return it.Elements[position-1], true, nil
+		}, nil
+
+	case *Map:
+		keys := it.Keys()
+		position := 0
+		return func() (Value, bool, error) {
+			if position >= len(keys) {
+				return nil, false, nil
+			}
+			position++
+			return keys[position-1], true, nil
+		}, nil
+
+	case Range:
+		current := it.Start
+		return func() (Value, bool, error) {
+			if current >= it.End {
+				return nil, false, nil
+			}
+			current++
+			return Number(current - 1), true, nil
+		}, nil
+	}
+
+	if TypeOf(iterable) == INSTANCE_TYPE {
+		return iterateInstance(iterable, callMethod)
+	}
+	return nil, ErrNotIterable
+}
+
+func iterateInstance(instance Value, callMethod MethodCaller) (Iterator, error) {
+	iter, err := callMethod(instance, "iter")
+	if err != nil {
+		return nil, err
+	}
+
+	if TypeOf(iter) != INSTANCE_TYPE {
+		return Iterate(iter, callMethod)
+	}
+
+	return func() (Value, bool, error) {
+		hasNext, err := callMethod(iter, "hasNext")
+		if err != nil {
+			return nil, false, err
+		}
+		if !IsTruthy(hasNext) {
+			return nil, false, nil
+		}
+
+		val, err := callMethod(iter, "next")
+		if err != nil {
+			return nil, false, err
+		}
+		return val, true, nil
+	}, nil
+}
@@ -0,0 +1,57 @@
+package value
+
+import "strings"
+
+// List A dynamic array of values.
+// Lists are shared by reference, so every variable holding
+// the list sees the changes made through any of them
+type List struct {
+	Elements []Value
+}
+
+func NewList(elements []Value) *List {
+	return &List{
+		Elements: elements,
+	}
+}
+
+func (l *List) Type() Type {
+	return LIST_TYPE
+}
+
+func (l *List) String() string {
+	return l.format(printing{})
+}
+
+func (l *List) format(p printing) string {
+	if p[l] {
+		return "[...]"
+	}
+	p[l] = true
+	defer delete(p, l)
+
+	elements := make([]string, 0, len(l.Elements))
+	for _, element := range l.Elements {
+		elements = append(elements, p.toString(element))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// printing The lists and maps being written out, so one which contains
+// itself is written as [...] or {...} where it repeats instead of forever
+type printing map[Value]bool
+
+func (p printing) toString(v Value) string {
+	switch c := v.(type) {
+	case *List:
+		return c.format(p)
+	case *Map:
+		return c.format(p)
+	}
+	return ToString(v)
+}
+
+func AsList(v Value) (*List, bool) {
+	l, ok := v.(*List)
+	return l, ok
+}
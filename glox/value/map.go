@@ -0,0 +1,100 @@
+package value
+
+import "strings"
+
+// Map An associative array of values which remembers the order
+// its keys were inserted in. Maps are shared by reference
+type Map struct {
+	keys    []Value
+	entries map[Value]Value
+}
+
+func NewMap() *Map {
+	return &Map{
+		keys:    make([]Value, 0),
+		entries: make(map[Value]Value),
+	}
+}
+
+func (m *Map) Get(key Value) (Value, bool) {
+	val, ok := m.entries[Key(key)]
+	return val, ok
+}
+
+// Set Adds the entry or replaces the value of an existing key
+func (m *Map) Set(key, val Value) {
+	key = Key(key)
+	if _, ok := m.entries[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.entries[key] = val
+}
+
+func (m *Map) Has(key Value) bool {
+	_, ok := m.entries[Key(key)]
+	return ok
+}
+
+// Delete Removes the entry and reports whether the key was present
+func (m *Map) Delete(key Value) bool {
+	key = Key(key)
+	if _, ok := m.entries[key]; !ok {
+		return false
+	}
+
+	delete(m.entries, key)
+	for k := range m.keys {
+		if m.keys[k] == key {
+			m.keys = append(m.keys[:k], m.keys[k+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+// Keys Returns a copy of the keys in insertion order
+func (m *Map) Keys() []Value {
+	keys := make([]Value, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+// Values Returns the values in the insertion order of their keys
+func (m *Map) Values() []Value {
+	values := make([]Value, 0, len(m.keys))
+	for _, key := range m.keys {
+		values = append(values, m.entries[key])
+	}
+	return values
+}
+
+func (m *Map) Type() Type {
+	return MAP_TYPE
+}
+
+func (m *Map) String() string {
+	return m.format(printing{})
+}
+
+func (m *Map) format(p printing) string {
+	if p[m] {
+		return "{...}"
+	}
+	p[m] = true
+	defer delete(p, m)
+
+	entries := make([]string, 0, len(m.keys))
+	for _, key := range m.keys {
+		entries = append(entries, p.toString(key)+": "+p.toString(m.entries[key]))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func AsMap(v Value) (*Map, bool) {
+	m, ok := v.(*Map)
+	return m, ok
+}
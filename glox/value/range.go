@@ -0,0 +1,16 @@
+package value
+
+// Range The numbers from Start up to but not including End, counting by one.
+// Ranges are iterated lazily so no list of the numbers is created
+type Range struct {
+	Start float64
+	End   float64
+}
+
+func (r Range) Type() Type {
+	return RANGE_TYPE
+}
+
+func (r Range) String() string {
+	return "range(" + Number(r.Start).String() + ", " + Number(r.End).String() + ")"
+}
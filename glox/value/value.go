@@ -0,0 +1,174 @@
+package value
+
+import "strconv"
+
+type Type int
+
+const (
+	NIL_TYPE Type = iota
+	BOOL_TYPE
+	NUMBER_TYPE
+	STRING_TYPE
+	CALLABLE_TYPE
+	CLASS_TYPE
+	INSTANCE_TYPE
+	LIST_TYPE
+	MAP_TYPE
+	RANGE_TYPE
+	MODULE_TYPE
+	ERROR_TYPE
+)
+
+func (t Type) String() string {
+	switch t {
+	case NIL_TYPE:
+		return "nil"
+	case BOOL_TYPE:
+		return "bool"
+	case NUMBER_TYPE:
+		return "number"
+	case STRING_TYPE:
+		return "string"
+	case CALLABLE_TYPE:
+		return "function"
+	case CLASS_TYPE:
+		return "class"
+	case INSTANCE_TYPE:
+		return "instance"
+	case LIST_TYPE:
+		return "list"
+	case MAP_TYPE:
+		return "map"
+	case RANGE_TYPE:
+		return "range"
+	case MODULE_TYPE:
+		return "module"
+	case ERROR_TYPE:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// Value Is implemented by every runtime value of Lox.
+// Object kinds such as functions, classes and instances live in
+// the interpreter and only need to report their type and text form
+type Value interface {
+	Type() Type
+	String() string
+}
+
+type NilValue struct{}
+
+// Nil The only value of NIL_TYPE
+var Nil = NilValue{}
+
+func (n NilValue) Type() Type {
+	return NIL_TYPE
+}
+
+func (n NilValue) String() string {
+	return "nil"
+}
+
+type Bool bool
+
+func (b Bool) Type() Type {
+	return BOOL_TYPE
+}
+
+func (b Bool) String() string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+type Number float64
+
+func (n Number) Type() Type {
+	return NUMBER_TYPE
+}
+
+func (n Number) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+type String string
+
+func (s String) Type() Type {
+	return STRING_TYPE
+}
+
+func (s String) String() string {
+	return string(s)
+}
+
+// TypeOf Returns the type of the value, treating Go nil as NIL_TYPE
+func TypeOf(v Value) Type {
+	if v == nil {
+		return NIL_TYPE
+	}
+	return v.Type()
+}
+
+func IsNil(v Value) bool {
+	return TypeOf(v) == NIL_TYPE
+}
+
+func AsNumber(v Value) (float64, bool) {
+	n, ok := v.(Number)
+	return float64(n), ok
+}
+
+func AsString(v Value) (string, bool) {
+	s, ok := v.(String)
+	return string(s), ok
+}
+
+func AsBool(v Value) (bool, bool) {
+	b, ok := v.(Bool)
+	return bool(b), ok
+}
+
+// ToString Returns the text form of the value used by print
+func ToString(v Value) string {
+	if v == nil {
+		return "nil"
+	}
+	return v.String()
+}
+
+// IsTruthy Only nil and false are falsey, every other value is truthy
+func IsTruthy(v Value) bool {
+	switch val := v.(type) {
+	case Bool:
+		return bool(val)
+	case NilValue, nil:
+		return false
+	}
+
+	return true
+}
+
+// Equal Values of different types are never equal and nil is only equal to nil.
+// Objects are compared by identity
+func Equal(a, b Value) bool {
+	if TypeOf(a) != TypeOf(b) {
+		return false
+	}
+	if TypeOf(a) == NIL_TYPE {
+		return true
+	}
+	return a == b
+}
+
+// Key Returns the value in the form used to hash it as a map key.
+// Nil, booleans, numbers and strings are hashed by value and objects
+// by identity, so two keys are the same entry exactly when they are Equal
+func Key(v Value) Value {
+	if v == nil {
+		return Nil
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package vm
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/madraceee/interpreters/glox/interpreter"
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// DefineNative Makes the native function available to every module
+func (vm *VM) DefineNative(fn *stdlib.NativeFunction) {
+	vm.builtins[fn.Name] = fn
+}
+
+// callValue Calls the callee below the arguments on the stack.
+// Closures get a new frame, everything else is done once this returns
+func (vm *VM) callValue(callee value.Value, argCount int) error {
+	switch c := callee.(type) {
+	case *Closure:
+		return vm.call(c, argCount, "")
+	case *BoundMethod:
+		vm.stack[vm.top-argCount-1] = c.Receiver
+		return vm.call(c.Method, argCount, "")
+	case *Class:
+		// Initializers show up in stack traces as a call of the class
+		vm.stack[vm.top-argCount-1] = NewInstance(c)
+		if initializer, ok := c.Methods["init"]; ok {
+			return vm.call(initializer, argCount, c.Name)
+		}
+		if argCount != 0 {
+			return vm.arityError(0, argCount)
+		}
+		if err := vm.enterCall(); err != nil {
+			return err
+		}
+		vm.top -= argCount
+		return nil
+	case *stdlib.NativeFunction:
+		return vm.callNative(c, argCount)
+	}
+
+	return vm.errorf("Can only call functions and classes")
+}
+
+func (vm *VM) call(closure *Closure, argCount int, name string) error {
+	if argCount != closure.Function.Arity {
+		return vm.arityError(closure.Function.Arity, argCount)
+	}
+	if err := vm.enterCall(); err != nil {
+		return err
+	}
+
+	vm.frames = append(vm.frames, frame{
+		closure: closure,
+		slots:   vm.top - argCount - 1,
+		name:    name,
+	})
+	return nil
+}
+
+// callNative Calls the native function and replaces it and the arguments with the result.
+// Errors are raised at the call with the native function on top of the stack trace
+func (vm *VM) callNative(native *stdlib.NativeFunction, argCount int) error {
+	if native.Arity != stdlib.VARIADIC && argCount != native.Arity {
+		return vm.arityError(native.Arity, argCount)
+	}
+	if err := vm.enterCall(); err != nil {
+		return err
+	}
+
+	arguments := make([]value.Value, argCount)
+	copy(arguments, vm.stack[vm.top-argCount:vm.top])
+	result, err := native.Call(vm.ctx, arguments)
+	if err == nil {
+		// The script may have been stopped while the native ran
+		err = vm.interrupted()
+	}
+	if err == nil {
+		if s, ok := value.AsString(result); ok && vm.limits.MaxStringLength > 0 && len(s) > vm.limits.MaxStringLength {
+			err = interpreter.ErrStringLimit
+		}
+	}
+	if err != nil {
+		runtimeError := &utils.RuntimeError{}
+		if !errors.As(err, &runtimeError) {
+			runtimeError = &utils.RuntimeError{Token: vm.token(), Err: err}
+		}
+		if runtimeError.Trace == nil {
+			trace := vm.stackTrace()
+			runtimeError.Trace = append([]utils.StackFrame{{
+				Function: native.Name,
+				File:     trace[0].File,
+				Line:     trace[0].Line,
+				Column:   trace[0].Column,
+			}}, trace...)
+		}
+		return runtimeError
+	}
+
+	vm.top -= argCount
+	vm.stack[vm.top-1] = result
+	return nil
+}
+
+// enterCall Returns an error if a call can't be made without going over the call depth.
+// The script itself is not counted
+func (vm *VM) enterCall() error {
+	depth := len(vm.frames)
+	if depth > 0 && vm.frames[0].name == "script" {
+		depth--
+	}
+	if depth >= vm.limits.MaxCallDepth {
+		return vm.error(interpreter.ErrStackLimit)
+	}
+	return nil
+}
+
+func (vm *VM) arityError(arity, argCount int) error {
+	return vm.error(errors.New("Expected " + strconv.Itoa(arity) + " arguments but got " + strconv.Itoa(argCount) + "."))
+}
+
+// getProperty Returns the field or bound method of an instance,
+// the member of a module or the property of an error object
+func (vm *VM) getProperty(object value.Value, name string) (value.Value, error) {
+	switch obj := object.(type) {
+	case *Instance:
+		if val, ok := obj.Fields[name]; ok {
+			return val, nil
+		}
+		if method, ok := obj.Class.Methods[name]; ok {
+			return &BoundMethod{Receiver: obj, Method: method}, nil
+		}
+		return nil, vm.errorf("Undefined property '%s'.", name)
+	case *Module:
+		at := vm.token()
+		at.Lexeme = name
+		return obj.Get(at)
+	case *interpreter.LoxError:
+		at := vm.token()
+		at.Lexeme = name
+		return obj.Get(at)
+	}
+
+	return nil, vm.errorf("Only instances, modules and errors have properties.")
+}
+
+// callMethod Calls the method of the instance without arguments
+// and runs it till it returns
+func (vm *VM) callMethod(receiver value.Value, name string) (value.Value, error) {
+	instance, ok := receiver.(*Instance)
+	if !ok {
+		return nil, vm.errorf("Only instances have methods.")
+	}
+	method, ok := instance.Class.Methods[name]
+	if !ok {
+		return nil, vm.errorf("%s has no method '%s'.", instance.String(), name)
+	}
+	if method.Function.Arity != 0 {
+		return nil, vm.errorf("Method '%s' must take no arguments.", name)
+	}
+
+	vm.push(instance)
+	if err := vm.call(method, 0, ""); err != nil {
+		vm.top--
+		return nil, err
+	}
+	return vm.run(len(vm.frames) - 1)
+}
@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"errors"
+	"math"
+
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+func (vm *VM) getIndex(object, index value.Value) (value.Value, error) {
+	switch collection := object.(type) {
+	case *value.List:
+		position, err := toIndex(index, len(collection.Elements))
+		if err != nil {
+			return nil, vm.error(err)
+		}
+		return collection.Elements[position], nil
+	case *value.Map:
+		val, ok := collection.Get(index)
+		if !ok {
+			return nil, vm.errorf("Undefined key '%s'.", value.ToString(index))
+		}
+		return val, nil
+	}
+
+	return nil, vm.errorf("Only lists and maps can be indexed.")
+}
+
+func (vm *VM) setIndex(object, index, val value.Value) error {
+	switch collection := object.(type) {
+	case *value.List:
+		position, err := toIndex(index, len(collection.Elements))
+		if err != nil {
+			return vm.error(err)
+		}
+		collection.Elements[position] = val
+		return nil
+	case *value.Map:
+		collection.Set(index, val)
+		return nil
+	}
+
+	return vm.errorf("Only lists and maps can be indexed.")
+}
+
+// toIndex Converts the value into a position in [0, length)
+func toIndex(index value.Value, length int) (int, error) {
+	number, ok := value.AsNumber(index)
+	if !ok || number != math.Trunc(number) {
+		return 0, errors.New("Index must be an integer.")
+	}
+	if number < 0 || number >= float64(length) {
+		return 0, errors.New("Index out of bounds.")
+	}
+	return int(number), nil
+}
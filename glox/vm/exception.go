@@ -0,0 +1,44 @@
+package vm
+
+import (
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/interpreter"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// pending An error pushed by a handler, till a catch
+// clause binds it or a finally body rethrows it
+type pending struct {
+	err *utils.RuntimeError
+}
+
+func (p *pending) Type() value.Type {
+	return value.ERROR_TYPE
+}
+
+func (p *pending) String() string {
+	return p.err.Error()
+}
+
+// handle Continues at the innermost handler of the frames from the index upward,
+// with the error pushed. Returns false if there is none or the error can't be caught
+func (vm *VM) handle(err error, base int) bool {
+	runtimeError := &utils.RuntimeError{}
+	if !errors.As(err, &runtimeError) || interpreter.IsFatal(err) {
+		return false
+	}
+	if len(vm.handlers) == 0 || vm.handlers[len(vm.handlers)-1].frame < base {
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	vm.closeUpvalues(h.top)
+	vm.frames = vm.frames[:h.frame+1]
+	vm.frames[h.frame].ip = h.ip
+	vm.top = h.top
+	vm.push(&pending{err: runtimeError})
+	return true
+}
@@ -0,0 +1,72 @@
+package vm
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// GetGlobal Returns the global variable of the script or the native with the name
+func (vm *VM) GetGlobal(name string) (value.Value, bool) {
+	if val, ok := vm.script.Globals[name]; ok {
+		return val, true
+	}
+	val, ok := vm.builtins[name]
+	return val, ok
+}
+
+// SetGlobal Defines or replaces the global variable of the script
+func (vm *VM) SetGlobal(name string, val value.Value) {
+	vm.script.Globals[name] = val
+}
+
+// Call Calls a function or class from the host program.
+// Runtime errors are reported to the collector and returned
+func (vm *VM) Call(callee value.Value, arguments []value.Value) (value.Value, error) {
+	arity := -1
+	switch c := callee.(type) {
+	case *Closure:
+		arity = c.Function.Arity
+	case *BoundMethod:
+		arity = c.Method.Function.Arity
+	case *Class:
+		arity = c.Arity()
+	case *stdlib.NativeFunction:
+		arity = c.Arity
+	default:
+		return nil, errors.New("Can only call functions and classes.")
+	}
+	if arity != stdlib.VARIADIC && len(arguments) != arity {
+		return nil, errors.New("Expected " + strconv.Itoa(arity) + " arguments but got " + strconv.Itoa(len(arguments)) + ".")
+	}
+
+	base := vm.top
+	frames := len(vm.frames)
+	vm.push(callee)
+	for _, argument := range arguments {
+		vm.push(argument)
+	}
+
+	result, err := vm.callHost(callee, len(arguments), frames)
+	vm.top = base
+	if err != nil {
+		err = vm.withStackTrace(err)
+		vm.reportRuntimeError(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// callHost Calls the callee on the stack, running it till it returns
+// if it needs a frame. frames is the number of frames before the call
+func (vm *VM) callHost(callee value.Value, argCount, frames int) (value.Value, error) {
+	if err := vm.callValue(callee, argCount); err != nil {
+		return nil, err
+	}
+	if len(vm.frames) == frames {
+		return vm.pop(), nil
+	}
+	return vm.run(frames)
+}
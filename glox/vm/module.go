@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+)
+
+// SetSearchPath Sets the directories searched for modules
+// which are not found relative to the importing file
+func (vm *VM) SetSearchPath(paths []string) {
+	vm.searchPath = paths
+}
+
+// importModule Returns the module at the path, running it the first time it is imported
+func (vm *VM) importModule(name string) (*Module, error) {
+	file, ok := vm.findModule(name)
+	if !ok {
+		return nil, vm.errorf("Can't find module '%s'.", name)
+	}
+
+	key, err := filepath.Abs(file)
+	if err != nil {
+		key = file
+	}
+	if module, ok := vm.modules[key]; ok {
+		return module, nil
+	}
+
+	for k := range vm.importing {
+		if vm.importing[k] == key {
+			cycle := append(append([]string{}, vm.importing[k:]...), key)
+			return nil, vm.errorf("Import cycle: %s.", strings.Join(cycle, " -> "))
+		}
+	}
+
+	function, err := vm.loadModule(name, file)
+	if err != nil {
+		return nil, err
+	}
+
+	module := NewModule(name, file)
+	vm.importing = append(vm.importing, key)
+	defer func() { vm.importing = vm.importing[:len(vm.importing)-1] }()
+
+	// Module body shows up in stack traces as a call from the import
+	vm.push(NewClosure(function, module))
+	if err := vm.call(vm.peek(0).(*Closure), 0, module.String()); err != nil {
+		vm.top--
+		return nil, err
+	}
+	if _, err := vm.run(len(vm.frames) - 1); err != nil {
+		return nil, err
+	}
+
+	vm.modules[key] = module
+	return module, nil
+}
+
+// loadModule Reads, parses, resolves and compiles the file of the module.
+// Static errors in the file are reported to the collector
+func (vm *VM) loadModule(name, file string) (*compiler.Function, error) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return nil, vm.errorf("Can't read module '%s'.", name)
+	}
+	vm.diag.AddSource(file, string(source))
+
+	diag := diagnostics.NewCollector()
+	tokens := scanner.NewScanner(string(source), diag).ScanTokens()
+	stmts, err := parser.NewParser(tokens, diag).Parse()
+	var function *compiler.Function
+	if err == nil && !diag.HasErrors() {
+		resolver.NewResolver(vm, diag).Resolve(stmts)
+		if !diag.HasErrors() {
+			function, _ = compiler.NewCompiler(diag).Compile(stmts)
+		}
+	}
+
+	for _, d := range diag.Diagnostics() {
+		d.File = file
+		vm.diag.Report(d)
+	}
+	if diag.HasErrors() {
+		return nil, vm.errorf("Module '%s' has errors.", name)
+	}
+	return function, nil
+}
+
+// findModule Looks for the module next to the importing file
+// and then in each directory of the search path
+func (vm *VM) findModule(name string) (string, bool) {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		importer := vm.frames[len(vm.frames)-1].closure.Module.File
+		candidates = []string{filepath.Join(filepath.Dir(importer), name)}
+		for _, dir := range vm.searchPath {
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"errors"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// Closure A compiled function with the variables it captured
+type Closure struct {
+	Function *compiler.Function
+	Upvalues []*Upvalue
+	// Module the function is declared in, whose globals it uses
+	Module *Module
+}
+
+func NewClosure(function *compiler.Function, module *Module) *Closure {
+	return &Closure{
+		Function: function,
+		Upvalues: make([]*Upvalue, function.UpvalueCount),
+		Module:   module,
+	}
+}
+
+func (c *Closure) Type() value.Type {
+	return value.CALLABLE_TYPE
+}
+
+func (c *Closure) String() string {
+	return c.Function.String()
+}
+
+// Upvalue A captured variable. It points at its stack slot
+// while open and holds the value itself once closed
+type Upvalue struct {
+	slot     int
+	closed   value.Value
+	isClosed bool
+}
+
+type Class struct {
+	Name string
+	// Methods of the superclass are copied in when the class inherits
+	Methods map[string]*Closure
+}
+
+func NewClass(name string) *Class {
+	return &Class{
+		Name:    name,
+		Methods: make(map[string]*Closure),
+	}
+}
+
+func (c *Class) Arity() int {
+	if initializer, ok := c.Methods["init"]; ok {
+		return initializer.Function.Arity
+	}
+	return 0
+}
+
+func (c *Class) Type() value.Type {
+	return value.CLASS_TYPE
+}
+
+func (c *Class) String() string {
+	return c.Name
+}
+
+type Instance struct {
+	Class  *Class
+	Fields map[string]value.Value
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{
+		Class:  class,
+		Fields: make(map[string]value.Value),
+	}
+}
+
+func (i *Instance) Type() value.Type {
+	return value.INSTANCE_TYPE
+}
+
+func (i *Instance) String() string {
+	return i.Class.Name + " instance"
+}
+
+// BoundMethod A method read from an instance, called with the instance as "this"
+type BoundMethod struct {
+	Receiver value.Value
+	Method   *Closure
+}
+
+func (b *BoundMethod) Type() value.Type {
+	return value.CALLABLE_TYPE
+}
+
+func (b *BoundMethod) String() string {
+	return b.Method.String()
+}
+
+// Module A file which runs in its own global namespace.
+// The globals it defines are the members other files can use
+type Module struct {
+	Name    string
+	File    string
+	Globals map[string]value.Value
+}
+
+func NewModule(name, file string) *Module {
+	return &Module{
+		Name:    name,
+		File:    file,
+		Globals: make(map[string]value.Value),
+	}
+}
+
+// Get Returns the global defined by the module.
+// Native functions are not members of the module
+func (m *Module) Get(name token.Token) (value.Value, error) {
+	if val, ok := m.Globals[name.Lexeme]; ok {
+		return val, nil
+	}
+
+	return nil, &utils.RuntimeError{
+		Token: name,
+		Err:   errors.New("Undefined member '" + name.Lexeme + "' of module '" + m.Name + "'."),
+	}
+}
+
+func (m *Module) Type() value.Type {
+	return value.MODULE_TYPE
+}
+
+func (m *Module) String() string {
+	return "<module " + m.Name + ">"
+}
@@ -0,0 +1,704 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/madraceee/interpreters/glox/compiler"
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/interpreter"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/stdlib"
+	"github.com/madraceee/interpreters/glox/token"
+	"github.com/madraceee/interpreters/glox/utils"
+	"github.com/madraceee/interpreters/glox/value"
+)
+
+// CHECK_INTERVAL Instructions run between checks of the context
+const CHECK_INTERVAL = 1024
+
+// frame A call of a closure which is currently executing
+type frame struct {
+	closure *Closure
+	// Offset of the next instruction
+	ip int
+	// Stack slot holding the callee, where the locals of the call start
+	slots int
+	// Name shown in stack traces, if it is not the name of the function
+	name string
+}
+
+// handler Where an error raised in a try body continues
+type handler struct {
+	// Index of the frame running the try and where it continues
+	frame int
+	ip    int
+	// Stack height when the try started
+	top int
+}
+
+// VM Runs compiled functions with a value stack.
+// Errors and stack traces match those of the tree-walking interpreter
+type VM struct {
+	stack []value.Value
+	top   int
+	// Calls which are currently executing, innermost last
+	frames []frame
+	// Upvalues still pointing at the stack, by ascending slot
+	openUpvalues []*Upvalue
+	// Handlers of the trys being run, innermost last
+	handlers []handler
+	// Native functions, available to every module
+	builtins map[string]value.Value
+	// Module of the file being run
+	script *Module
+	// Executed modules by absolute path, so each one runs once
+	modules map[string]*Module
+	// Absolute paths of the modules being imported, outermost first
+	importing []string
+	// Directories searched for modules not found next to the importing file
+	searchPath []string
+	// Where print writes to
+	out io.Writer
+	// Context which stops the script and the channel it is done on
+	ctx    context.Context
+	done   <-chan struct{}
+	limits interpreter.Limits
+	// Instructions run since the context was set
+	steps int
+	diag  *diagnostics.Collector
+}
+
+func New(diag *diagnostics.Collector) *VM {
+	vm := &VM{
+		stack:        make([]value.Value, 256),
+		frames:       make([]frame, 0, 64),
+		openUpvalues: make([]*Upvalue, 0),
+		handlers:     make([]handler, 0),
+		builtins:     make(map[string]value.Value),
+		script:       NewModule("script", "script"),
+		modules:      make(map[string]*Module),
+		diag:         diag,
+	}
+
+	stdlib.Install(vm, stdlib.Collections, stdlib.Math, stdlib.String, stdlib.Time)
+	vm.SetIO(os.Stdin, os.Stdout)
+	vm.DefineNative(interpreter.ErrorNative)
+	vm.SetContext(context.Background())
+	vm.SetLimits(interpreter.Limits{})
+	return vm
+}
+
+// SetIO Sets where print and the io functions write to and read from
+func (vm *VM) SetIO(in io.Reader, out io.Writer) {
+	vm.out = out
+	stdlib.Install(vm, stdlib.IO(in, out))
+}
+
+// SetFileName Sets the file name shown in stack traces.
+// Imports in the script are resolved relative to it
+func (vm *VM) SetFileName(fileName string) {
+	vm.script.File = fileName
+
+	// Script counts as being imported so importing it back is a cycle
+	if path, err := filepath.Abs(fileName); err == nil {
+		vm.importing = []string{path}
+	}
+}
+
+// SetContext Sets the context which stops the script once it is done.
+// The step budget starts over for every context
+func (vm *VM) SetContext(ctx context.Context) {
+	vm.ctx = ctx
+	vm.done = ctx.Done()
+	vm.steps = 0
+}
+
+// SetLimits Bounds the work of the script. Steps are counted in instructions
+func (vm *VM) SetLimits(limits interpreter.Limits) {
+	if limits.MaxCallDepth <= 0 {
+		limits.MaxCallDepth = interpreter.DEFAULT_MAX_CALL_DEPTH
+	}
+	vm.limits = limits
+}
+
+// Resolve Does nothing, the compiler resolves variables into stack slots itself
+func (vm *VM) Resolve(expr parser.Expr, depth, slot int) {}
+
+// Interpret Compiles and runs the statements. Compile errors and the
+// runtime error which stopped the script are reported to the collector
+func (vm *VM) Interpret(stmts []parser.Stmt) error {
+	script, err := compiler.NewCompiler(vm.diag).Compile(stmts)
+	if err != nil {
+		return err
+	}
+	return vm.Run(script)
+}
+
+// Run Runs the compiled script in the globals of the script
+func (vm *VM) Run(script *compiler.Function) error {
+	vm.push(NewClosure(script, vm.script))
+	vm.frames = append(vm.frames, frame{
+		closure: vm.stack[vm.top-1].(*Closure),
+		slots:   vm.top - 1,
+		name:    "script",
+	})
+
+	if _, err := vm.run(len(vm.frames) - 1); err != nil {
+		vm.reportRuntimeError(err)
+		return err
+	}
+	return nil
+}
+
+func (vm *VM) reportRuntimeError(err error) {
+	runtimeError := &utils.RuntimeError{}
+	if !errors.As(err, &runtimeError) {
+		vm.diag.Report(diagnostics.Diagnostic{
+			Severity: diagnostics.ERROR,
+			Code:     diagnostics.RUNTIME_ERROR,
+			Message:  err.Error(),
+		})
+		return
+	}
+
+	// Errors raised inside an imported module point into its file
+	file := ""
+	if len(runtimeError.Trace) > 0 && runtimeError.Trace[0].File != vm.script.File {
+		file = runtimeError.Trace[0].File
+	}
+	vm.diag.Report(diagnostics.Diagnostic{
+		Severity: diagnostics.ERROR,
+		Code:     diagnostics.RUNTIME_ERROR,
+		Message:  runtimeError.Err.Error(),
+		Span:     runtimeError.Token.Span(),
+		Notes:    runtimeError.Notes(),
+		File:     file,
+	})
+}
+
+// Stack
+func (vm *VM) push(v value.Value) {
+	if vm.top == len(vm.stack) {
+		vm.stack = append(vm.stack, make([]value.Value, len(vm.stack))...)
+	}
+	vm.stack[vm.top] = v
+	vm.top++
+}
+
+func (vm *VM) pop() value.Value {
+	vm.top--
+	return vm.stack[vm.top]
+}
+
+func (vm *VM) peek(distance int) value.Value {
+	return vm.stack[vm.top-1-distance]
+}
+
+// step Counts an instruction and returns an error once the
+// budget is spent or the context is done
+func (vm *VM) step() error {
+	vm.steps++
+	if vm.limits.MaxSteps > 0 && vm.steps > vm.limits.MaxSteps {
+		return interpreter.ErrStepLimit
+	}
+	if vm.steps%CHECK_INTERVAL == 0 {
+		return vm.interrupted()
+	}
+	return nil
+}
+
+// interrupted Returns the error of the context if it is done
+func (vm *VM) interrupted() error {
+	select {
+	case <-vm.done:
+		return vm.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// run Runs the frame at the index till it returns, along with the calls it makes.
+// Errors are handled by the trys of those frames, else the frames are unwound
+// and the error is returned
+func (vm *VM) run(base int) (value.Value, error) {
+	for {
+		result, err := vm.dispatch(base)
+		if err == nil {
+			return result, nil
+		}
+
+		err = vm.withStackTrace(err)
+		if !vm.handle(err, base) {
+			vm.unwind(base)
+			return nil, err
+		}
+	}
+}
+
+// dispatch Runs instructions till the frame at the index returns or an error is raised
+func (vm *VM) dispatch(base int) (value.Value, error) {
+	frame := &vm.frames[len(vm.frames)-1]
+	chunk := frame.closure.Function.Chunk
+	code := chunk.Code
+	globals := frame.closure.Module.Globals
+
+	readShort := func() int {
+		frame.ip += 2
+		return int(code[frame.ip-2])<<8 | int(code[frame.ip-1])
+	}
+	readString := func() string {
+		name, _ := value.AsString(chunk.Constants[readShort()])
+		return name
+	}
+	// reload Switches to the innermost frame after a call or return
+	reload := func() {
+		frame = &vm.frames[len(vm.frames)-1]
+		chunk = frame.closure.Function.Chunk
+		code = chunk.Code
+		globals = frame.closure.Module.Globals
+	}
+
+	for {
+		if err := vm.step(); err != nil {
+			return nil, vm.error(err)
+		}
+
+		op := compiler.OpCode(code[frame.ip])
+		frame.ip++
+
+		switch op {
+		case compiler.OP_CONSTANT:
+			vm.push(chunk.Constants[readShort()])
+		case compiler.OP_NIL:
+			vm.push(value.Nil)
+		case compiler.OP_TRUE:
+			vm.push(value.Bool(true))
+		case compiler.OP_FALSE:
+			vm.push(value.Bool(false))
+		case compiler.OP_POP:
+			vm.top--
+
+		case compiler.OP_GET_LOCAL:
+			slot := int(code[frame.ip])
+			frame.ip++
+			vm.push(vm.stack[frame.slots+slot])
+		case compiler.OP_SET_LOCAL:
+			slot := int(code[frame.ip])
+			frame.ip++
+			vm.stack[frame.slots+slot] = vm.peek(0)
+
+		case compiler.OP_GET_GLOBAL:
+			name := readString()
+			val, ok := globals[name]
+			if !ok {
+				val, ok = vm.builtins[name]
+			}
+			if !ok {
+				return nil, vm.errorf("Undefined variable '%s'", name)
+			}
+			vm.push(val)
+		case compiler.OP_DEFINE_GLOBAL:
+			globals[readString()] = vm.pop()
+		case compiler.OP_SET_GLOBAL:
+			// Assigning a native replaces it for every module, like the tree-walker
+			name := readString()
+			if _, ok := globals[name]; ok {
+				globals[name] = vm.peek(0)
+			} else if _, ok := vm.builtins[name]; ok {
+				vm.builtins[name] = vm.peek(0)
+			} else {
+				return nil, vm.errorf("Undefined variable '%s'.", name)
+			}
+
+		case compiler.OP_GET_UPVALUE:
+			up := frame.closure.Upvalues[code[frame.ip]]
+			frame.ip++
+			if up.isClosed {
+				vm.push(up.closed)
+			} else {
+				vm.push(vm.stack[up.slot])
+			}
+		case compiler.OP_SET_UPVALUE:
+			up := frame.closure.Upvalues[code[frame.ip]]
+			frame.ip++
+			if up.isClosed {
+				up.closed = vm.peek(0)
+			} else {
+				vm.stack[up.slot] = vm.peek(0)
+			}
+
+		case compiler.OP_GET_PROPERTY:
+			val, err := vm.getProperty(vm.peek(0), readString())
+			if err != nil {
+				return nil, err
+			}
+			vm.stack[vm.top-1] = val
+		case compiler.OP_SET_PROPERTY:
+			name := readString()
+			instance, ok := vm.peek(1).(*Instance)
+			if !ok {
+				return nil, vm.errorf("Only instances have fields.")
+			}
+			val := vm.pop()
+			instance.Fields[name] = val
+			vm.stack[vm.top-1] = val
+		case compiler.OP_GET_SUPER:
+			name := readString()
+			superclass, ok := vm.pop().(*Class)
+			if !ok {
+				return nil, vm.errorf("Superclass must be a class.")
+			}
+			method, ok := superclass.Methods[name]
+			if !ok {
+				return nil, vm.errorf("Undefined property '%s'.", name)
+			}
+			vm.stack[vm.top-1] = &BoundMethod{Receiver: vm.peek(0), Method: method}
+
+		case compiler.OP_GET_INDEX:
+			val, err := vm.getIndex(vm.peek(1), vm.peek(0))
+			if err != nil {
+				return nil, err
+			}
+			vm.top--
+			vm.stack[vm.top-1] = val
+		case compiler.OP_SET_INDEX:
+			val := vm.peek(0)
+			if err := vm.setIndex(vm.peek(2), vm.peek(1), val); err != nil {
+				return nil, err
+			}
+			vm.top -= 2
+			vm.stack[vm.top-1] = val
+
+		case compiler.OP_EQUAL:
+			b := vm.pop()
+			vm.stack[vm.top-1] = value.Bool(value.Equal(vm.peek(0), b))
+		case compiler.OP_GREATER, compiler.OP_GREATER_EQUAL, compiler.OP_LESS, compiler.OP_LESS_EQUAL,
+			compiler.OP_SUBTRACT, compiler.OP_MULTIPLY, compiler.OP_DIVIDE:
+			a, aOk := value.AsNumber(vm.peek(1))
+			b, bOk := value.AsNumber(vm.peek(0))
+			if !aOk || !bOk {
+				return nil, vm.errorf("Operands must be numbers.")
+			}
+			if op == compiler.OP_DIVIDE && b == 0 {
+				return nil, vm.errorf("cannot divide by 0")
+			}
+			vm.top--
+			vm.stack[vm.top-1] = arithmetic(op, a, b)
+		case compiler.OP_ADD:
+			if err := vm.add(); err != nil {
+				return nil, err
+			}
+		case compiler.OP_NOT:
+			vm.stack[vm.top-1] = value.Bool(!value.IsTruthy(vm.peek(0)))
+		case compiler.OP_NEGATE:
+			n, ok := value.AsNumber(vm.peek(0))
+			if !ok {
+				return nil, vm.errorf("Operand must be a number.")
+			}
+			vm.stack[vm.top-1] = value.Number(-n)
+		case compiler.OP_PRINT:
+			fmt.Fprintln(vm.out, value.ToString(vm.pop()))
+
+		case compiler.OP_JUMP:
+			offset := readShort()
+			frame.ip += offset
+		case compiler.OP_JUMP_IF_FALSE:
+			offset := readShort()
+			if !value.IsTruthy(vm.peek(0)) {
+				frame.ip += offset
+			}
+		case compiler.OP_LOOP:
+			offset := readShort()
+			frame.ip -= offset
+
+		case compiler.OP_CALL:
+			argCount := int(code[frame.ip])
+			frame.ip++
+			if err := vm.callValue(vm.peek(argCount), argCount); err != nil {
+				return nil, err
+			}
+			reload()
+		case compiler.OP_CLOSURE:
+			function := chunk.Constants[readShort()].(*compiler.Function)
+			closure := NewClosure(function, frame.closure.Module)
+			for k := range closure.Upvalues {
+				isLocal, index := code[frame.ip], int(code[frame.ip+1])
+				frame.ip += 2
+				if isLocal == 1 {
+					closure.Upvalues[k] = vm.captureUpvalue(frame.slots + index)
+				} else {
+					closure.Upvalues[k] = frame.closure.Upvalues[index]
+				}
+			}
+			vm.push(closure)
+		case compiler.OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(vm.top - 1)
+			vm.top--
+		case compiler.OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(frame.slots)
+			vm.dropHandlers(len(vm.frames) - 1)
+
+			vm.top = frame.slots
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == base {
+				return result, nil
+			}
+			vm.push(result)
+			reload()
+
+		case compiler.OP_CLASS:
+			vm.push(NewClass(readString()))
+		case compiler.OP_INHERIT:
+			superclass, ok := vm.peek(1).(*Class)
+			if !ok {
+				return nil, vm.errorf("Superclass must be a class.")
+			}
+			subclass, ok := vm.pop().(*Class)
+			if !ok {
+				return nil, vm.errorf("Only classes can inherit.")
+			}
+			for name, method := range superclass.Methods {
+				subclass.Methods[name] = method
+			}
+		case compiler.OP_METHOD:
+			name := readString()
+			class, ok := vm.peek(1).(*Class)
+			method, isClosure := vm.peek(0).(*Closure)
+			if !ok || !isClosure {
+				return nil, vm.errorf("Methods can only be defined on classes.")
+			}
+			class.Methods[name] = method
+			vm.top--
+
+		case compiler.OP_LIST:
+			count := readShort()
+			elements := make([]value.Value, count)
+			copy(elements, vm.stack[vm.top-count:vm.top])
+			vm.top -= count
+			vm.push(value.NewList(elements))
+		case compiler.OP_MAP:
+			count := readShort()
+			entries := value.NewMap()
+			for k := vm.top - 2*count; k < vm.top; k += 2 {
+				entries.Set(vm.stack[k], vm.stack[k+1])
+			}
+			vm.top -= 2 * count
+			vm.push(entries)
+
+		case compiler.OP_ITERATE:
+			next, err := value.Iterate(vm.peek(0), vm.callMethod)
+			reload()
+			if err == value.ErrNotIterable {
+				return nil, vm.error(err)
+			}
+			if err != nil {
+				return nil, err
+			}
+			vm.stack[vm.top-1] = next
+		case compiler.OP_FOR_NEXT:
+			offset := readShort()
+			next, ok := vm.peek(0).(value.Iterator)
+			if !ok {
+				return nil, vm.errorf("Can only loop over an iterator.")
+			}
+			val, ok, err := next()
+			reload()
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				vm.push(val)
+			} else {
+				frame.ip += offset
+			}
+
+		case compiler.OP_IMPORT:
+			module, err := vm.importModule(readString())
+			reload()
+			if err != nil {
+				return nil, err
+			}
+			vm.push(module)
+
+		case compiler.OP_THROW:
+			return nil, vm.error(&interpreter.Thrown{Value: vm.pop()})
+		case compiler.OP_TRY:
+			offset := readShort()
+			vm.handlers = append(vm.handlers, handler{
+				frame: len(vm.frames) - 1,
+				ip:    frame.ip + offset,
+				top:   vm.top,
+			})
+		case compiler.OP_END_TRY:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case compiler.OP_CATCH:
+			raised, ok := vm.peek(0).(*pending)
+			if !ok {
+				return nil, vm.errorf("Can only catch a thrown error.")
+			}
+			vm.stack[vm.top-1] = interpreter.Caught(raised.err)
+		case compiler.OP_RETHROW:
+			raised, ok := vm.pop().(*pending)
+			if !ok {
+				return nil, vm.errorf("Can only rethrow a thrown error.")
+			}
+			return nil, raised.err
+
+		default:
+			return nil, vm.errorf("Unknown opcode %d.", op)
+		}
+	}
+}
+
+// arithmetic Applies the operator of the instruction to numbers
+func arithmetic(op compiler.OpCode, a, b float64) value.Value {
+	switch op {
+	case compiler.OP_GREATER:
+		return value.Bool(a > b)
+	case compiler.OP_GREATER_EQUAL:
+		return value.Bool(a >= b)
+	case compiler.OP_LESS:
+		return value.Bool(a < b)
+	case compiler.OP_LESS_EQUAL:
+		return value.Bool(a <= b)
+	case compiler.OP_SUBTRACT:
+		return value.Number(a - b)
+	case compiler.OP_MULTIPLY:
+		return value.Number(a * b)
+	}
+	return value.Number(a / b)
+}
+
+// add Adds two numbers or concatenates two strings
+func (vm *VM) add() error {
+	left, right := vm.peek(1), vm.peek(0)
+	if a, ok := value.AsNumber(left); ok {
+		if b, ok := value.AsNumber(right); ok {
+			vm.top--
+			vm.stack[vm.top-1] = value.Number(a + b)
+			return nil
+		}
+	}
+	if a, ok := value.AsString(left); ok {
+		if b, ok := value.AsString(right); ok {
+			if max := vm.limits.MaxStringLength; max > 0 && len(a)+len(b) > max {
+				return vm.error(interpreter.ErrStringLimit)
+			}
+			vm.top--
+			vm.stack[vm.top-1] = value.String(a + b)
+			return nil
+		}
+	}
+
+	return vm.errorf("Operands must be two numbers or two strings.")
+}
+
+// Upvalues
+// captureUpvalue Returns the open upvalue of the slot, creating it if there is none
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	k := len(vm.openUpvalues)
+	for k > 0 && vm.openUpvalues[k-1].slot >= slot {
+		if vm.openUpvalues[k-1].slot == slot {
+			return vm.openUpvalues[k-1]
+		}
+		k--
+	}
+
+	up := &Upvalue{slot: slot}
+	vm.openUpvalues = append(vm.openUpvalues, nil)
+	copy(vm.openUpvalues[k+1:], vm.openUpvalues[k:])
+	vm.openUpvalues[k] = up
+	return up
+}
+
+// closeUpvalues Moves the variables of the slots from last upward off the stack
+func (vm *VM) closeUpvalues(last int) {
+	k := len(vm.openUpvalues)
+	for k > 0 && vm.openUpvalues[k-1].slot >= last {
+		up := vm.openUpvalues[k-1]
+		up.closed = vm.stack[up.slot]
+		up.isClosed = true
+		k--
+	}
+	vm.openUpvalues = vm.openUpvalues[:k]
+}
+
+// Errors
+// token Returns the token covering the code of the current instruction
+func (vm *VM) token() token.Token {
+	f := vm.frames[len(vm.frames)-1]
+	return token.FromSpan(f.closure.Function.Chunk.SpanAt(f.ip - 1))
+}
+
+// error Returns a RuntimeError at the current instruction
+func (vm *VM) error(err error) error {
+	return &utils.RuntimeError{Token: vm.token(), Err: err}
+}
+
+func (vm *VM) errorf(format string, args ...interface{}) error {
+	return vm.error(fmt.Errorf(format, args...))
+}
+
+// withStackTrace Attaches the current call stack to a runtime error
+// which does not have one yet
+func (vm *VM) withStackTrace(err error) error {
+	runtimeError := &utils.RuntimeError{}
+	if !errors.As(err, &runtimeError) || runtimeError.Trace != nil {
+		return err
+	}
+
+	runtimeError.Trace = vm.stackTrace()
+	return err
+}
+
+// stackTrace Returns the frames of the call stack, innermost first.
+// Calls made by the host end with the script they were made from
+func (vm *VM) stackTrace() []utils.StackFrame {
+	trace := make([]utils.StackFrame, 0, len(vm.frames)+1)
+	for k := len(vm.frames) - 1; k >= 0; k-- {
+		f := vm.frames[k]
+		span := f.closure.Function.Chunk.SpanAt(f.ip - 1)
+		trace = append(trace, utils.StackFrame{
+			Function: f.Name(),
+			File:     f.closure.Module.File,
+			Line:     span.Start.Line,
+			Column:   span.Start.Column,
+		})
+	}
+
+	if len(vm.frames) == 0 || vm.frames[0].name != "script" {
+		trace = append(trace, utils.StackFrame{Function: "script", File: vm.script.File})
+	}
+	return trace
+}
+
+// Name Returns the name of the frame shown in stack traces
+func (f *frame) Name() string {
+	if f.name != "" {
+		return f.name
+	}
+	return f.closure.Function.Name
+}
+
+// unwind Removes the frames from the index upward after an error which was not handled
+func (vm *VM) unwind(base int) {
+	vm.dropHandlers(base)
+	vm.closeUpvalues(vm.frames[base].slots)
+	vm.top = vm.frames[base].slots
+	vm.frames = vm.frames[:base]
+}
+
+// dropHandlers Removes the handlers of the frames from the index upward
+func (vm *VM) dropHandlers(base int) {
+	k := len(vm.handlers)
+	for k > 0 && vm.handlers[k-1].frame >= base {
+		k--
+	}
+	vm.handlers = vm.handlers[:k]
+}
@@ -0,0 +1,270 @@
+package vm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madraceee/interpreters/glox/diagnostics"
+	"github.com/madraceee/interpreters/glox/interpreter"
+	"github.com/madraceee/interpreters/glox/parser"
+	"github.com/madraceee/interpreters/glox/resolver"
+	"github.com/madraceee/interpreters/glox/scanner"
+	"github.com/madraceee/interpreters/glox/utils"
+)
+
+// run Runs the source as the file with the engine made by create.
+// Returns what the script printed followed by the rendered diagnostics
+func run(t *testing.T, fileName, source string, create engine) (string, error) {
+	t.Helper()
+	diag := diagnostics.NewCollector()
+	diag.AddSource(fileName, source)
+
+	tokens := scanner.NewScanner(source, diag).ScanTokens()
+	stmts, err := parser.NewParser(tokens, diag).Parse()
+	if err != nil || diag.HasErrors() {
+		t.Fatalf("parse error in %q: %v", source, diag.Diagnostics())
+	}
+
+	out := &bytes.Buffer{}
+	locals, interpret := create(diag, out)
+	resolver.NewResolver(locals, diag).Resolve(stmts)
+	if diag.HasErrors() {
+		t.Fatalf("resolve error in %q: %v", source, diag.Diagnostics())
+	}
+
+	err = interpret(stmts)
+	diag.Render(out, source)
+	return out.String(), err
+}
+
+// engine Creates the tree-walker or the VM printing to out.
+// Returns it and the function which runs the resolved statements
+type engine func(diag *diagnostics.Collector, out *bytes.Buffer) (resolver.Locals, func([]parser.Stmt) error)
+
+func treeWalker(fileName string, setup func(i *interpreter.Interpreter)) engine {
+	return func(diag *diagnostics.Collector, out *bytes.Buffer) (resolver.Locals, func([]parser.Stmt) error) {
+		i := interpreter.NewInterpreter(diag)
+		i.SetIO(strings.NewReader(""), out)
+		i.SetFileName(fileName)
+		setup(i)
+		return i, i.Interpret
+	}
+}
+
+func bytecode(fileName string, setup func(vm *VM)) engine {
+	return func(diag *diagnostics.Collector, out *bytes.Buffer) (resolver.Locals, func([]parser.Stmt) error) {
+		vm := New(diag)
+		vm.SetIO(strings.NewReader(""), out)
+		vm.SetFileName(fileName)
+		setup(vm)
+		return vm, vm.Interpret
+	}
+}
+
+// compare Runs the source on the tree-walker and the VM
+// and fails if the output or the errors differ
+func compare(t *testing.T, fileName, source string, searchPath ...string) {
+	t.Helper()
+	want, wantErr := run(t, fileName, source, treeWalker(fileName, func(i *interpreter.Interpreter) {
+		i.SetSearchPath(searchPath)
+	}))
+	got, gotErr := run(t, fileName, source, bytecode(fileName, func(vm *VM) {
+		vm.SetSearchPath(searchPath)
+	}))
+
+	if got != want {
+		t.Errorf("output of %q differs\nvm:\n%s\ntree-walker:\n%s", source, got, want)
+	}
+	if (gotErr == nil) != (wantErr == nil) {
+		t.Errorf("error = %v, tree-walker error = %v", gotErr, wantErr)
+	}
+}
+
+func TestSameOutputAsTreeWalker(t *testing.T) {
+	iterators := `
+class Counter {
+  init(n) { this.n = n; }
+  iter() { return CounterIter(this.n); }
+}
+class CounterIter {
+  init(n) { this.i = 0; this.n = n; }
+  hasNext() { return this.i < this.n; }
+  next() { this.i = this.i + 1; return this.i; }
+}
+`
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"arithmetic", `print 1 + 2 * 3 - 4 / 2; print -(3); print 7 >= 7; print 2 < 1; print "a" + "b";`},
+		{"equality", `print 1 == 1; print "a" != "b"; print nil == false; print !nil;`},
+		{"logical", `print nil or "x"; print 1 and 2; print false and missing;`},
+		{"globals", `var a = 1; a = a + 1; print a; var b; print b;`},
+		{"locals and shadowing", `var a = "global"; { var a = "outer"; { var a = "inner"; print a; } print a; } print a;`},
+		{"if", `if (1 > 2) print "then"; else print "else"; if (nil) print "no";`},
+		{"while", `var i = 0; while (i < 3) { print i; i = i + 1; }`},
+		{"for", `for (var i = 0; i < 3; i = i + 1) print i;`},
+		{"break and continue", `for (var i = 0; i < 10; i = i + 1) { if (i == 1) continue; if (i == 4) break; print i; } var j = 0; while (true) { j = j + 1; if (j < 3) continue; break; } print j;`},
+		{"functions", `fun add(a, b) { return a + b; } print add(1, 2); print add; fun none() {} print none();`},
+		{"recursion", `fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); } print fib(15);`},
+		{"closures", `fun counter() { var n = 0; fun inc() { n = n + 1; return n; } return inc; } var c = counter(); c(); print c(); var d = counter(); print d();`},
+		{"shared upvalue", `var get; var set; { var x = 1; get = () => x; set = (v) => x = v; } set(5); print get();`},
+		{"closure per iteration", `var fs = []; for (x in [1, 2, 3]) push(fs, () => x); for (f in fs) print f();`},
+		{"closure in while", `var fs = []; var i = 0; while (i < 3) { var j = i; push(fs, () => j); i = i + 1; } for (f in fs) print f();`},
+		{"classes", `class A { init(x) { this.x = x; } get() { return this.x; } } var a = A(3); print a.get(); print a; print A; a.y = 4; print a.y;`},
+		{"init returns this", `class A { init() { this.v = 1; return; } } var a = A(); print a.init(); print a.v;`},
+		{"inheritance", `class A { hi() { return "A"; } name() { return "a"; } } class B < A { hi() { return "B" + super.hi(); } } var b = B(); print b.hi(); print b.name();`},
+		{"super in closure", `class A { f() { return 1; } } class B < A { f() { var g = () => super.f() + 1; return g(); } } print B().f();`},
+		{"bound method", `class A { init() { this.v = 2; } get() { return this.v; } } var m = A().get; print m();`},
+		{"field holding function", `class A {} var a = A(); a.f = (x) => x * 2; print a.f(4);`},
+		{"lists", `var xs = [1, "a", nil]; print xs; xs[0] = 5; print xs[0]; print len(xs); push(xs, 4); print xs;`},
+		{"maps", `var m = {"a": 1, 2: nil}; print m; m["b"] = 3; print m["b"]; print keys(m);`},
+		{"compound index", `var xs = [[1, 2], [3]]; xs[0][1] = 9; print xs; var m = {"k": [1]}; print m["k"][0];`},
+		{"for in", iterators + `for (c in "ab") print c; for (k in {"x": 1}) print k; for (n in range(1, 3)) print n; for (v in Counter(2)) print v;`},
+		{"lambdas", `var add = fun (a, b) { return a + b; }; print add(1, 2); print ((x) => x * x)(4); print fun () {};`},
+		{"natives", `print len("abc"); print clock() > 0; print floor(2.5); print upper("ab"); print sqrt;`},
+		{"exceptions", `try { throw "oops"; } catch (e) { print e; } try { 1 / 0; } catch (e) { print e.message; print e.line; }`},
+		{"error object", `fun f() { throw Error("x"); } try { f(); } catch (e) { print e.message; print len(e.stack); print e; }`},
+		{"finally", `try { throw 1; } catch (e) { print e; } finally { print "f"; } try { try { throw 2; } finally { print "inner"; } } catch (e) { print e; }`},
+		{"finally on return", `fun f() { try { return 1; } finally { print "f"; } } print f(); fun g() { try { return 1; } finally { return 2; } } print g();`},
+		{"finally on break and continue", `for (x in range(0, 5)) { try { if (x == 1) continue; if (x == 3) break; } finally { print x; } }`},
+		{"rethrow from catch", `try { try { throw 1; } catch (e) { throw e + 1; } } catch (e) { print e; }`},
+		{"catch in called function", `fun f() { throw "deep"; } fun g() { f(); print "no"; } try { g(); } catch (e) { print e; } print "after";`},
+		{"catch from iterator", `class It { iter() { throw "iter"; } } try { for (x in It()) print x; } catch (e) { print e; }`},
+		{"catch from native", `try { pop([]); } catch (e) { print e.message; print e.stack; }`},
+		{"stack overflow is caught", `fun f() { f(); } try { f(); } catch (e) { print e.message; }`},
+		{"uncaught throw", `fun f() { throw "oops"; } f();`},
+		{"undefined variable", `print missing;`},
+		{"assign undefined", `missing = 1;`},
+		{"type error", `fun f() { return 1 + nil; } fun g() { return f(); } g();`},
+		{"negate", `-"a";`},
+		{"compare", `1 < "a";`},
+		{"divide by zero", `print 1; 1 / 0;`},
+		{"call non function", `"a"();`},
+		{"arity", `fun f(a) {} f(1, 2);`},
+		{"class arity", `class A {} A(1);`},
+		{"property of non instance", `print 1.x;`},
+		{"undefined property", `class A {} A().x;`},
+		{"field of non instance", `var x = 1; x.y = 2;`},
+		{"superclass", `var x = 1; class A < x {}`},
+		{"index errors", `var xs = [1]; xs[1];`},
+		{"index non integer", `[1][0.5];`},
+		{"index non collection", `"ab"[0];`},
+		{"missing key", `var m = {}; m["k"];`},
+		{"iterate non iterable", `for (x in 1) print x;`},
+		{"iterator without iter", `class A {} for (x in A()) print x;`},
+		{"native error in initializer", `class A { init() { pop([]); } } A();`},
+		{"recursion overflow", `fun f(n) { return f(n + 1); } f(0);`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, "script", tt.source)
+		})
+	}
+}
+
+// writeFiles Creates the files under dir, keyed by their relative path
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImportSameAsTreeWalker(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/counter.lox": `
+var count = 0;
+fun increment() { count = count + 1; return count; }
+`,
+		"lib/uses.lox":      `import "counter.lox" as c; var first = c.increment();`,
+		"search/shared.lox": `var name = "shared";`,
+		"a.lox":             `import "b.lox" as b;`,
+		"b.lox":             `import "a.lox" as a;`,
+		"bad.lox":           `var = 1;`,
+		"fails.lox":         `fun f() { return nil + 1; } f();`,
+	})
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"modules", `
+import "lib/counter.lox" as counter;
+import "lib/uses.lox" as uses;
+import "shared.lox" as shared;
+var count = 100;
+counter.increment();
+print [counter.count, uses.first, count, shared.name];
+print counter;
+`},
+		{"missing module", `import "missing.lox" as m;`},
+		{"cycle", `import "a.lox" as a;`},
+		{"undefined member", `import "lib/counter.lox" as m; m.y;`},
+		{"natives are not members", `import "lib/counter.lox" as m; m.len;`},
+		{"module with errors", `import "bad.lox" as m;`},
+		{"runtime error in module", `import "fails.lox" as m;`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, filepath.Join(dir, "main.lox"), tt.source, filepath.Join(dir, "search"))
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		limits interpreter.Limits
+		want   error
+	}{
+		{"step limit", `while (true) {}`, interpreter.Limits{MaxSteps: 1000}, interpreter.ErrStepLimit},
+		{"step limit is not caught", `try { while (true) {} } catch (e) {}`, interpreter.Limits{MaxSteps: 1000}, interpreter.ErrStepLimit},
+		{"call depth", `fun f(n) { if (n > 0) f(n - 1); } f(10);`, interpreter.Limits{MaxCallDepth: 5}, interpreter.ErrStackLimit},
+		{"string concatenation", `var s = "ab"; while (true) s = s + s;`, interpreter.Limits{MaxStringLength: 64}, interpreter.ErrStringLimit},
+		{"string from native", `join(["abc", "def"], "");`, interpreter.Limits{MaxStringLength: 4}, interpreter.ErrStringLimit},
+		{"string is not caught", `try { "abc" + "def"; } catch (e) {}`, interpreter.Limits{MaxStringLength: 4}, interpreter.ErrStringLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, "script", tt.source, bytecode("script", func(vm *VM) {
+				vm.SetLimits(tt.limits)
+			}))
+			if _, ok := err.(*utils.RuntimeError); !ok {
+				t.Fatalf("error is %T, want *utils.RuntimeError", err)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := run(t, "script", `while (true) { try {} catch (e) {} }`, bytecode("script", func(vm *VM) {
+		vm.SetContext(ctx)
+	}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}